@@ -0,0 +1,60 @@
+// Package bingo ビンゴのルール（カードの生成、数字の抽選、勝ちの形の判定）を提供する
+//
+// HTTPやWebSocketには依存しないため、サーバー以外（チャットボットやCLIなど）からも同じルールで利用できる
+package bingo
+
+import "math/rand"
+
+// カードに関する定数
+const (
+	CardSize  = 5  // カードの一辺のマス数
+	FreeSpace = 0  // 中央のFREEマスを表す値
+	MaxNumber = 75 // カードと抽選で使う数字の最大値
+)
+
+// Card ビンゴカード（中央のFREEマスは0）
+type Card [CardSize][CardSize]int
+
+// Marks カードの各マスがマークされているかどうか
+type Marks [CardSize][CardSize]bool
+
+// NewCard 1からMaxNumberまでの重複しない数字でビンゴカードを生成する
+// rngがnilの場合はパッケージ共通の乱数を使う
+func NewCard(rng *rand.Rand) Card {
+	var card Card
+	used := make(map[int]bool) // 使用済みの数字を管理するマップ
+
+	for i := 0; i < CardSize; i++ {
+		for j := 0; j < CardSize; j++ {
+			num := intn(rng, MaxNumber) + 1
+			for used[num] {
+				num = intn(rng, MaxNumber) + 1 // 既に使用されている場合は再生成
+			}
+			used[num] = true
+			card[i][j] = num
+		}
+	}
+
+	card[2][2] = FreeSpace // 中央のマスはFREE
+	return card
+}
+
+// Contains カードに数字が含まれているかどうかを返す
+func (card Card) Contains(number int) bool {
+	for i := 0; i < CardSize; i++ {
+		for j := 0; j < CardSize; j++ {
+			if card[i][j] == number {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 乱数を返す関数（rngがnilの場合はパッケージ共通の乱数を使う）
+func intn(rng *rand.Rand, n int) int {
+	if rng == nil {
+		return rand.Intn(n)
+	}
+	return rng.Intn(n)
+}
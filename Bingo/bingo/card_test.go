@@ -0,0 +1,89 @@
+package bingo
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestNewCard(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 100; n++ {
+		card := NewCard(rng)
+		if card[2][2] != FreeSpace {
+			t.Fatalf("中央のマスがFREEではありません: %d", card[2][2])
+		}
+
+		seen := make(map[int]bool)
+		for i := 0; i < CardSize; i++ {
+			for j := 0; j < CardSize; j++ {
+				if i == 2 && j == 2 {
+					continue
+				}
+				num := card[i][j]
+				if num < 1 || num > MaxNumber {
+					t.Fatalf("範囲外の数字があります: card[%d][%d] = %d", i, j, num)
+				}
+				if seen[num] {
+					t.Fatalf("数字が重複しています: %d", num)
+				}
+				seen[num] = true
+			}
+		}
+	}
+}
+
+func TestNewCardSameSeed(t *testing.T) {
+	a := NewCard(rand.New(rand.NewSource(42)))
+	b := NewCard(rand.New(rand.NewSource(42)))
+	if a != b {
+		t.Fatalf("同じ乱数源から異なるカードが生成されました:\n%v\n%v", a, b)
+	}
+}
+
+func TestCardContains(t *testing.T) {
+	card := testCard()
+	if !card.Contains(1) || !card.Contains(75) {
+		t.Fatal("カードの数字が見つかりません")
+	}
+	if card.Contains(30) {
+		t.Fatal("カードにない数字が見つかりました")
+	}
+}
+
+func TestDeckDrawsEveryNumberOnce(t *testing.T) {
+	deck := NewDeck(rand.New(rand.NewSource(7)))
+	seen := make(map[int]bool)
+	for {
+		num, ok := deck.Draw()
+		if !ok {
+			break
+		}
+		if num < 1 || num > MaxNumber || seen[num] {
+			t.Fatalf("無効または重複した数字が引かれました: %d", num)
+		}
+		seen[num] = true
+	}
+	if len(seen) != MaxNumber || deck.Remaining() != 0 {
+		t.Fatalf("引かれた数字の数 = %d, 残り = %d", len(seen), deck.Remaining())
+	}
+}
+
+func TestLetter(t *testing.T) {
+	tests := map[int]string{1: "B", 15: "B", 16: "I", 31: "N", 46: "G", 75: "O", 0: "", 76: ""}
+	for num, want := range tests {
+		if got := Letter(num); got != want {
+			t.Errorf("Letter(%d) = %q, want %q", num, got, want)
+		}
+	}
+}
+
+// testCard 判定のテストに使う固定のカード（行ごとに1〜5, 6〜10, ... と並べる）
+func testCard() Card {
+	return Card{
+		{1, 2, 3, 4, 5},
+		{6, 7, 8, 9, 10},
+		{11, 12, FreeSpace, 14, 15},
+		{16, 17, 18, 19, 20},
+		{21, 22, 23, 24, 75},
+	}
+}
@@ -0,0 +1,54 @@
+package bingo
+
+// Checker 引かれた数字をもとにカードのマーク状態と勝ちを判定する
+// プレイヤーが手元でつけたマークではなく、実際に引かれた数字だけで判定する
+type Checker struct {
+	called [MaxNumber + 1]bool // 引かれた数字
+}
+
+// NewChecker 引かれた数字を指定してCheckerを作成する
+func NewChecker(numbers ...int) *Checker {
+	checker := &Checker{}
+	for _, n := range numbers {
+		checker.Call(n)
+	}
+	return checker
+}
+
+// Call 数字が引かれたことを記録する（範囲外の数字は無視する）
+func (c *Checker) Call(number int) {
+	if number >= 1 && number <= MaxNumber {
+		c.called[number] = true
+	}
+}
+
+// Called 数字が引かれているかどうかを返す
+func (c *Checker) Called(number int) bool {
+	return number >= 1 && number <= MaxNumber && c.called[number]
+}
+
+// Marks 引かれた数字をもとにカードのマーク状態を作る（FREEマスは常にマーク済み）
+func (c *Checker) Marks(card Card) Marks {
+	var marks Marks
+	for i := 0; i < CardSize; i++ {
+		for j := 0; j < CardSize; j++ {
+			marks[i][j] = card[i][j] == FreeSpace || c.Called(card[i][j])
+		}
+	}
+	return marks
+}
+
+// Check カードが形を満たしていれば、満たした組み合わせの名前を返す
+func (c *Checker) Check(card Card, pattern Pattern) (string, bool) {
+	return pattern.Match(c.Marks(card))
+}
+
+// Missing カードが形を満たすためにあと何マス必要かを返す
+func (c *Checker) Missing(card Card, pattern Pattern) int {
+	return pattern.Missing(c.Marks(card))
+}
+
+// Lines カードの縦・横・斜めの列ごとに、揃うまでにあと何マス必要かを返す
+func (c *Checker) Lines(card Card) map[string]int {
+	return LineMissing(c.Marks(card))
+}
@@ -0,0 +1,60 @@
+package bingo
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Draw 一回の抽選の記録
+type Draw struct {
+	Ordinal int       `json:"ordinal"` // 何番目に引かれた数字か（1始まり）
+	Number  int       `json:"number"`  // 引かれた数字
+	Letter  string    `json:"letter"`  // 数字に対応する列の文字（B-I-N-G-O）
+	Time    time.Time `json:"time"`    // 引かれた時刻
+}
+
+// Letter 数字に対応するB-I-N-G-Oの文字を返す（範囲外の場合は空文字）
+func Letter(number int) string {
+	const letters = "BINGO"
+	if number < 1 || number > MaxNumber {
+		return ""
+	}
+	return string(letters[(number-1)/15])
+}
+
+// Deck まだ引かれていない数字の山
+type Deck struct {
+	remaining []int      // まだ引かれていない数字
+	rng       *rand.Rand // 抽選に使う乱数（nilの場合はパッケージ共通の乱数）
+}
+
+// NewDeck 1からMaxNumberまでの数字の山を作成する
+// rngがnilの場合はパッケージ共通の乱数を使う
+func NewDeck(rng *rand.Rand) *Deck {
+	deck := &Deck{remaining: make([]int, 0, MaxNumber), rng: rng}
+	for n := 1; n <= MaxNumber; n++ {
+		deck.remaining = append(deck.remaining, n)
+	}
+	return deck
+}
+
+// Draw 山から数字を一つ引く（すべて引き終えている場合はfalseを返す）
+func (deck *Deck) Draw() (int, bool) {
+	if len(deck.remaining) == 0 {
+		return 0, false
+	}
+	i := intn(deck.rng, len(deck.remaining))
+	number := deck.remaining[i]
+	deck.remaining = append(deck.remaining[:i], deck.remaining[i+1:]...)
+	return number, true
+}
+
+// Remaining まだ引かれていない数字の数を返す
+func (deck *Deck) Remaining() int {
+	return len(deck.remaining)
+}
+
+// clone 同じ状態の山を複製する
+func (deck *Deck) clone() *Deck {
+	return &Deck{remaining: append([]int(nil), deck.remaining...), rng: deck.rng}
+}
@@ -0,0 +1,108 @@
+package bingo
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Game 一回のゲーム（数字の山、引かれた数字、勝ちとなる形）
+// 並行して使う場合は呼び出し側で排他制御すること
+type Game struct {
+	Pattern Pattern // 勝ちとなる形
+
+	deck    *Deck   // まだ引かれていない数字
+	draws   []Draw  // 引かれた数字（引かれた順）
+	checker Checker // 引かれた数字による判定
+}
+
+// NewGame 形を指定して新しいゲームを作成する
+// rngがnilの場合はパッケージ共通の乱数を使う
+func NewGame(pattern Pattern, rng *rand.Rand) *Game {
+	return &Game{Pattern: pattern, deck: NewDeck(rng)}
+}
+
+// Draw 数字を一つ引いて記録する（すべて引き終えている場合はfalseを返す）
+func (g *Game) Draw(at time.Time) (Draw, bool) {
+	number, ok := g.deck.Draw()
+	if !ok {
+		return Draw{}, false
+	}
+	draw := Draw{
+		Ordinal: len(g.draws) + 1,
+		Number:  number,
+		Letter:  Letter(number),
+		Time:    at,
+	}
+	g.draws = append(g.draws, draw)
+	g.checker.Call(number)
+	return draw, true
+}
+
+// Draws 引かれた数字を引かれた順に返す（返り値を変更しないこと）
+func (g *Game) Draws() []Draw {
+	return g.draws
+}
+
+// Last 最後に引かれた数字を返す（まだ引かれていない場合はfalseを返す）
+func (g *Game) Last() (Draw, bool) {
+	if len(g.draws) == 0 {
+		return Draw{}, false
+	}
+	return g.draws[len(g.draws)-1], true
+}
+
+// Checker 引かれた数字による判定を返す
+func (g *Game) Checker() *Checker {
+	return &g.checker
+}
+
+// CheckerAfter 最初のn個より後に引かれた数字だけによる判定を返す
+// ゲームの途中で配られたカードを、配られる前の数字で判定しないために使う
+func (g *Game) CheckerAfter(n int) *Checker {
+	if n <= 0 {
+		return &g.checker
+	}
+	checker := &Checker{}
+	for _, draw := range g.draws[min(n, len(g.draws)):] {
+		checker.Call(draw.Number)
+	}
+	return checker
+}
+
+// CompletedAt 最初のn個より後に引かれた数字だけで、カードが形を初めて満たした抽選の番号を返す
+// まだ満たしていない場合はfalseを返す
+func (g *Game) CompletedAt(card Card, pattern Pattern, n int) (int, bool) {
+	checker := &Checker{}
+	for _, draw := range g.draws[min(max(n, 0), len(g.draws)):] {
+		checker.Call(draw.Number)
+		if _, ok := checker.Check(card, pattern); ok {
+			return draw.Ordinal, true
+		}
+	}
+	return 0, false
+}
+
+// Marks 引かれた数字をもとにカードのマーク状態を作る
+func (g *Game) Marks(card Card) Marks {
+	return g.checker.Marks(card)
+}
+
+// Check カードがゲームの形を満たしていれば、満たした組み合わせの名前を返す
+func (g *Game) Check(card Card) (string, bool) {
+	return g.checker.Check(card, g.Pattern)
+}
+
+// Missing カードがゲームの形を満たすためにあと何マス必要かを返す
+func (g *Game) Missing(card Card) int {
+	return g.checker.Missing(card, g.Pattern)
+}
+
+// Clone 同じ状態のゲームを複製する（乱数は共有する）
+func (g *Game) Clone() *Game {
+	return &Game{
+		Pattern: g.Pattern,
+		deck:    g.deck.clone(),
+		draws:   append([]Draw(nil), g.draws...),
+		checker: g.checker,
+	}
+}
@@ -0,0 +1,134 @@
+package bingo
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestCheckerIgnoresOutOfRange(t *testing.T) {
+	checker := NewChecker(0, 76, -1, 10)
+	if checker.Called(0) || checker.Called(76) || checker.Called(-1) {
+		t.Fatal("範囲外の数字が引かれたことになっています")
+	}
+	if !checker.Called(10) {
+		t.Fatal("引かれた数字が記録されていません")
+	}
+}
+
+func TestCheckerMarksFreeSpace(t *testing.T) {
+	marks := NewChecker().Marks(testCard())
+	if !marks[2][2] {
+		t.Fatal("FREEマスがマークされていません")
+	}
+	if marks[0][0] {
+		t.Fatal("引かれていない数字がマークされています")
+	}
+}
+
+func TestGameDraw(t *testing.T) {
+	game := NewGame(PatternLine, rand.New(rand.NewSource(3)))
+	if _, ok := game.Last(); ok {
+		t.Fatal("まだ引かれていないのに最後の数字があります")
+	}
+
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= MaxNumber; i++ {
+		draw, ok := game.Draw(at)
+		if !ok {
+			t.Fatalf("%d回目の抽選に失敗しました", i)
+		}
+		if draw.Ordinal != i || draw.Letter != Letter(draw.Number) || !draw.Time.Equal(at) {
+			t.Fatalf("抽選の記録が正しくありません: %+v", draw)
+		}
+		if !game.Checker().Called(draw.Number) {
+			t.Fatalf("引かれた数字 %d が判定に反映されていません", draw.Number)
+		}
+	}
+	if _, ok := game.Draw(at); ok {
+		t.Fatal("すべて引き終えた後に数字が引かれました")
+	}
+	if len(game.Draws()) != MaxNumber {
+		t.Fatalf("抽選の記録の数 = %d, want %d", len(game.Draws()), MaxNumber)
+	}
+}
+
+func TestGameCheck(t *testing.T) {
+	card := testCard()
+	game := NewGame(PatternFourCorners, rand.New(rand.NewSource(5)))
+	for {
+		if _, ok := game.Check(card); ok {
+			break
+		}
+		if _, ok := game.Draw(time.Time{}); !ok {
+			t.Fatal("すべて引き終えても四隅が揃いませんでした")
+		}
+	}
+	for _, num := range []int{1, 5, 21, 75} {
+		if !game.Checker().Called(num) {
+			t.Fatalf("四隅の数字 %d が引かれていないのに揃いました", num)
+		}
+	}
+	if game.Missing(card) != 0 {
+		t.Fatalf("揃ったカードの残りのマス = %d", game.Missing(card))
+	}
+}
+
+func TestGameCheckerAfter(t *testing.T) {
+	game := NewGame(PatternLine, rand.New(rand.NewSource(9)))
+	for i := 0; i < 10; i++ {
+		game.Draw(time.Time{})
+	}
+	draws := game.Draws()
+
+	if game.CheckerAfter(0) != game.Checker() {
+		t.Fatal("CheckerAfter(0) はすべての数字による判定を返すべきです")
+	}
+	after := game.CheckerAfter(4)
+	for i, draw := range draws {
+		if got, want := after.Called(draw.Number), i >= 4; got != want {
+			t.Fatalf("%d番目の数字 %d: Called = %v, want %v", draw.Ordinal, draw.Number, got, want)
+		}
+	}
+	// 引かれた数より大きい場合は何も引かれていないものとして扱う
+	for _, draw := range draws {
+		if game.CheckerAfter(20).Called(draw.Number) {
+			t.Fatalf("配られる前の数字 %d で判定されました", draw.Number)
+		}
+	}
+}
+
+func TestGameClone(t *testing.T) {
+	game := NewGame(PatternLine, rand.New(rand.NewSource(11)))
+	game.Draw(time.Time{})
+	clone := game.Clone()
+	clone.Draw(time.Time{})
+
+	if len(game.Draws()) != 1 || len(clone.Draws()) != 2 {
+		t.Fatalf("複製への抽選が元のゲームに影響しました: %d, %d", len(game.Draws()), len(clone.Draws()))
+	}
+	if game.Checker().Called(clone.Draws()[1].Number) {
+		t.Fatal("複製で引かれた数字が元のゲームの判定に反映されました")
+	}
+}
+
+func TestGameCompletedAt(t *testing.T) {
+	card := testCard()
+	game := NewGame(PatternFourCorners, rand.New(rand.NewSource(13)))
+	for {
+		if _, ok := game.Check(card); ok {
+			break
+		}
+		game.Draw(time.Time{})
+	}
+	completed := len(game.Draws())
+	game.Draw(time.Time{}) // 揃った後に引かれた数字は番号に影響しない
+
+	if got, ok := game.CompletedAt(card, PatternFourCorners, 0); !ok || got != completed {
+		t.Fatalf("CompletedAt = (%d, %v), want (%d, true)", got, ok, completed)
+	}
+	// 揃った抽選より後に配られたカードは、それまでの数字では揃わない
+	if _, ok := game.CompletedAt(card, PatternFourCorners, completed); ok {
+		t.Fatal("配られる前の数字で揃ったことになっています")
+	}
+}
@@ -0,0 +1,147 @@
+package bingo
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Pattern ラウンドで勝ちとなる形
+type Pattern string
+
+// 勝ちとなる形の一覧
+const (
+	PatternLine        Pattern = "line"         // 縦・横・斜めのいずれか一列
+	PatternTwoLines    Pattern = "two-lines"    // 二列
+	PatternFourCorners Pattern = "four-corners" // 四隅
+	PatternX           Pattern = "x"            // 斜め二本（X字）
+	PatternBlackout    Pattern = "blackout"     // 全マス
+)
+
+// patternShape 形を満たすマスの組み合わせ（5x5のマスを25ビットで表す）
+type patternShape struct {
+	Name string // 組み合わせの名前（row-1, column-3 など）
+	Mask uint32 // 必要なマスのビット
+}
+
+// 縦・横・斜めの12本の列
+var lineShapes = buildLineShapes()
+
+// 形ごとに、どれか一つを満たせば勝ちとなる組み合わせの一覧
+var patternShapes = buildPatternShapes()
+
+// マスの位置に対応するビットを返す関数
+func cellBit(i, j int) uint32 {
+	return 1 << uint(i*5+j)
+}
+
+// マーク状態をビットに変換する関数
+func markedMask(marked Marks) uint32 {
+	var mask uint32
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 5; j++ {
+			if marked[i][j] {
+				mask |= cellBit(i, j)
+			}
+		}
+	}
+	return mask
+}
+
+// missing 組み合わせを満たすためにマーク済みのマスに加えて必要なマスの数を返す
+func (shape patternShape) missing(mask uint32) int {
+	return bits.OnesCount32(shape.Mask &^ mask)
+}
+
+// 12本の列を作成する関数（横5本、縦5本、斜め2本の順）
+func buildLineShapes() []patternShape {
+	var shapes []patternShape
+	for i := 0; i < 5; i++ {
+		var row uint32
+		for j := 0; j < 5; j++ {
+			row |= cellBit(i, j)
+		}
+		shapes = append(shapes, patternShape{Name: fmt.Sprintf("row-%d", i+1), Mask: row})
+	}
+	for j := 0; j < 5; j++ {
+		var column uint32
+		for i := 0; i < 5; i++ {
+			column |= cellBit(i, j)
+		}
+		shapes = append(shapes, patternShape{Name: fmt.Sprintf("column-%d", j+1), Mask: column})
+	}
+
+	var down, up uint32
+	for i := 0; i < 5; i++ {
+		down |= cellBit(i, i)
+		up |= cellBit(i, 4-i)
+	}
+	return append(shapes,
+		patternShape{Name: "diagonal-down", Mask: down},
+		patternShape{Name: "diagonal-up", Mask: up},
+	)
+}
+
+// 形ごとの組み合わせを作成する関数
+func buildPatternShapes() map[Pattern][]patternShape {
+	var twoLines []patternShape
+	for a := 0; a < len(lineShapes); a++ {
+		for b := a + 1; b < len(lineShapes); b++ {
+			twoLines = append(twoLines, patternShape{
+				Name: lineShapes[a].Name + "+" + lineShapes[b].Name,
+				Mask: lineShapes[a].Mask | lineShapes[b].Mask,
+			})
+		}
+	}
+
+	return map[Pattern][]patternShape{
+		PatternLine:        lineShapes,
+		PatternTwoLines:    twoLines,
+		PatternFourCorners: {{Name: "four-corners", Mask: cellBit(0, 0) | cellBit(0, 4) | cellBit(4, 0) | cellBit(4, 4)}},
+		PatternX:           {{Name: "x", Mask: lineShapes[10].Mask | lineShapes[11].Mask}},
+		PatternBlackout:    {{Name: "blackout", Mask: 1<<25 - 1}},
+	}
+}
+
+// Valid 対応している形かどうかを返す
+func (p Pattern) Valid() bool {
+	_, ok := patternShapes[p]
+	return ok
+}
+
+// Match マーク状態が形を満たしていれば、満たした組み合わせの名前を返す
+func (p Pattern) Match(marked Marks) (string, bool) {
+	mask := markedMask(marked)
+	for _, shape := range patternShapes[p] {
+		if shape.Mask&^mask == 0 {
+			return shape.Name, true
+		}
+	}
+	return "", false
+}
+
+// Missing 形を満たすためにあと何マス必要かを返す
+func (p Pattern) Missing(marked Marks) int {
+	mask := markedMask(marked)
+	missing := 25
+	for _, shape := range patternShapes[p] {
+		missing = min(missing, shape.missing(mask))
+	}
+	return missing
+}
+
+// LineMissing 縦・横・斜めの列ごとに、揃うまでにあと何マス必要かを返す
+func LineMissing(marked Marks) map[string]int {
+	mask := markedMask(marked)
+	missing := make(map[string]int, len(lineShapes))
+	for _, shape := range lineShapes {
+		missing[shape.Name] = shape.missing(mask)
+	}
+	return missing
+}
+
+// WinningLine 揃っている列の名前を返す（揃っていない場合は空文字を返す）
+// 横・縦・斜め（右下がり、右上がり）の順に確認する
+func WinningLine(marked Marks) string {
+	name, _ := PatternLine.Match(marked)
+	return name
+}
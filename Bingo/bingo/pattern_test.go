@@ -0,0 +1,86 @@
+package bingo
+
+import "testing"
+
+func TestPatternMatch(t *testing.T) {
+	card := testCard()
+	tests := []struct {
+		name    string
+		pattern Pattern
+		called  []int
+		want    string
+		ok      bool
+	}{
+		{"横一列", PatternLine, []int{1, 2, 3, 4, 5}, "row-1", true},
+		{"FREEを含む横一列", PatternLine, []int{11, 12, 14, 15}, "row-3", true},
+		{"縦一列", PatternLine, []int{1, 6, 11, 16, 21}, "column-1", true},
+		{"右下がりの斜め", PatternLine, []int{1, 7, 19, 75}, "diagonal-down", true},
+		{"右上がりの斜め", PatternLine, []int{5, 9, 17, 21}, "diagonal-up", true},
+		{"一マス足りない", PatternLine, []int{1, 2, 3, 4}, "", false},
+		{"二列", PatternTwoLines, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, "row-1+row-2", true},
+		{"一列だけでは二列にならない", PatternTwoLines, []int{1, 2, 3, 4, 5}, "", false},
+		{"四隅", PatternFourCorners, []int{1, 5, 21, 75}, "four-corners", true},
+		{"X字", PatternX, []int{1, 7, 19, 75, 5, 9, 17, 21}, "x", true},
+		{"斜め一本ではX字にならない", PatternX, []int{1, 7, 19, 75}, "", false},
+		{"全マス", PatternBlackout, cardNumbers(card), "blackout", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NewChecker(tt.called...).Check(card, tt.pattern)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("Check(%s) = (%q, %v), want (%q, %v)", tt.pattern, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPatternMissing(t *testing.T) {
+	card := testCard()
+	checker := NewChecker(1, 2, 3)
+	tests := map[Pattern]int{
+		PatternLine:        2, // row-1
+		PatternTwoLines:    5, // row-1 + column-3（3とFREEを共有する）
+		PatternFourCorners: 3,
+		PatternX:           7,
+		PatternBlackout:    21,
+	}
+	for pattern, want := range tests {
+		if got := checker.Missing(card, pattern); got != want {
+			t.Errorf("Missing(%s) = %d, want %d", pattern, got, want)
+		}
+	}
+}
+
+func TestPatternValid(t *testing.T) {
+	for _, p := range []Pattern{PatternLine, PatternTwoLines, PatternFourCorners, PatternX, PatternBlackout} {
+		if !p.Valid() {
+			t.Errorf("%s が無効と判定されました", p)
+		}
+	}
+	if Pattern("zigzag").Valid() {
+		t.Error("未対応の形が有効と判定されました")
+	}
+}
+
+func TestLineMissing(t *testing.T) {
+	lines := NewChecker(11, 12).Lines(testCard())
+	if len(lines) != 12 {
+		t.Fatalf("列の数 = %d, want 12", len(lines))
+	}
+	if lines["row-3"] != 2 || lines["column-3"] != 4 || lines["row-1"] != 5 {
+		t.Fatalf("列ごとの残りのマスが正しくありません: %v", lines)
+	}
+}
+
+// cardNumbers カードのFREEマス以外の数字を返す
+func cardNumbers(card Card) []int {
+	var numbers []int
+	for i := 0; i < CardSize; i++ {
+		for j := 0; j < CardSize; j++ {
+			if card[i][j] != FreeSpace {
+				numbers = append(numbers, card[i][j])
+			}
+		}
+	}
+	return numbers
+}
@@ -1,6 +1,12 @@
 let ws; // WebSocketインスタンスを保持する変数
 let generateNumbersEnabled = false; // 数字生成が有効かどうかのフラグ。初期状態はfalse
 let roomPassword = ''; // ルームのパスワードをグローバル変数として宣言
+let hostToken = ''; // ルーム作成時に発行されるホスト用トークン
+let playerToken = ''; // ルーム参加時に発行されるプレイヤー用トークン
+let cardId = ''; // 配られたビンゴカードのID
+let cardIssuedAfter = 0; // カードが配られた時点で既に引かれていた数字の数（これより前の数字はマークできない）
+let roundPattern = 'line'; // 現在の賞で勝ちとなる形
+let serverShuttingDown = false; // サーバーから停止の通知を受け取ったかどうか
 
 // セッションストレージに保存するキーを定義
 const SESSION_STORAGE_KEY = 'bingoGameState';
@@ -19,6 +25,10 @@ function saveGameStateToSessionStorage() {
         bingoCardState: serializeBingoCardState(), // ビンゴカードの状態をシリアライズして保存
         generatedNumbers: generatedNumbers, // 生成された数字の配列を保存
         roomPassword: roomPassword, // ルームのパスワードを保存
+        hostToken: hostToken, // ホスト用トークンを保存
+        playerToken: playerToken, // プレイヤー用トークンを保存
+        cardId: cardId, // ビンゴカードのIDを保存
+        cardIssuedAfter: cardIssuedAfter, // カードが配られた時点の引かれた数字の数を保存
         styleState: serializeStyleState()  // スタイルの状態をシリアライズして保存
     };
     const serializedGameState = JSON.stringify(gameState); // ゲーム状態をJSON文字列に変換
@@ -50,6 +60,22 @@ function restoreGameStateFromSessionStorage() {
             roomPassword = gameState.roomPassword;
         }
 
+        // ホスト用トークンを復元
+        if (gameState.hostToken) {
+            hostToken = gameState.hostToken;
+        }
+
+        // プレイヤー用トークンとカードIDを復元
+        if (gameState.playerToken) {
+            playerToken = gameState.playerToken;
+        }
+        if (gameState.cardId) {
+            cardId = gameState.cardId;
+        }
+        if (gameState.cardIssuedAfter) {
+            cardIssuedAfter = gameState.cardIssuedAfter;
+        }
+
         // スタイルの状態を復元
         if (gameState.styleState) {
             deserializeStyleState(gameState.styleState);
@@ -143,7 +169,7 @@ function initializeWebSocket() {
         console.log('WebSocket接続が確立された.');
         // パスワードが設定されていればルームに参加
         if (roomPassword) {
-            ws.send(JSON.stringify({ type: 'join', password: roomPassword }));
+            sendWebSocketJoin();
         }
     };
 
@@ -158,16 +184,64 @@ function initializeWebSocket() {
 
     ws.onclose = function(event) {
         console.log('WebSocket接続が閉じた:', event);
-        setTimeout(initializeWebSocket, 1000); // 1秒後に再接続を試行
+        const delay = serverShuttingDown ? 5000 : 1000; // サーバーの停止中は再起動を待ってから再接続する
+        serverShuttingDown = false;
+        setTimeout(initializeWebSocket, delay);
     };
 }
 
+// WebSocketでルームに参加する関数（トークンがあれば同じプレイヤーとして再接続する）
+function sendWebSocketJoin() {
+    if (ws && ws.readyState === WebSocket.OPEN) {
+        ws.send(JSON.stringify({ type: 'join', password: roomPassword, playerToken: playerToken, hostToken: hostToken }));
+    }
+}
+
 // WebSocketメッセージの処理
 function handleWebSocketMessage(event) {
     try {
         const message = JSON.parse(event.data);
-        if (message.type === 'number') {
-            handleNewNumber(message.number); // 新しい数字を処理
+        if (message.type === 'draw') {
+            handleNewNumber(message.data.number); // 新しい数字を処理
+        } else if (message.type === 'winner') {
+            const win = message.data;
+            console.log(`${win.place}位: ${win.playerName} (${win.prize})`);
+            logDiv.appendChild(Object.assign(document.createElement('div'), { textContent: `🎉 ${win.place}位: ${win.playerName}` })); // 勝者をログに表示
+        } else if (message.type === 'round') {
+            generatedNumbers = []; // 新しいラウンドのために数字をリセット
+            cardIssuedAfter = 0;
+            roundPattern = message.data.pattern || roundPattern; // 新しいラウンドの形
+            console.log(`ラウンド${message.data.number}: ${message.data.pattern}`);
+        } else if (message.type === 'daub') {
+            if (message.data.cardId === cardId) {
+                applyMarks(message.data.marked); // サーバーが自動でマークした状態を反映する
+            }
+        } else if (message.type === 'claim_rejected') {
+            const claim = message.data;
+            const notes = { warn: '警告', cooldown: `${claim.cooldown}秒間申告できません`, disqualify: 'このカードは失格です' };
+            alert(`お手つきです（${notes[claim.action] || ''}）`); // お手つきへの対応を表示
+        } else if (message.type === 'false_claim_alert') {
+            const claim = message.data;
+            logDiv.appendChild(Object.assign(document.createElement('div'), { textContent: `⚠ ${claim.playerName} のお手つきが${claim.falseCount}回になりました` })); // ホストに通知
+        } else if (message.type === 'tie') {
+            const names = message.data.claims.filter(claim => claim.won).map(claim => claim.playerName).join(', ');
+            logDiv.appendChild(Object.assign(document.createElement('div'), { textContent: `同時ビンゴ ${message.data.claims.length}人 → ${names}` })); // 同時の申告の結果を表示
+        } else if (message.type === 'reach') {
+            const reach = message.data;
+            logDiv.appendChild(Object.assign(document.createElement('div'), { textContent: `リーチ！ ${reach.playerName || ''}（${reach.players}人）` })); // リーチを表示
+        } else if (message.type === 'prize') {
+            roundPattern = message.data.pattern || roundPattern; // 次の賞の形
+            logDiv.appendChild(Object.assign(document.createElement('div'), { textContent: `次の賞: ${message.data.prize}` })); // 次の賞を表示
+        } else if (message.type === 'round_end') {
+            logDiv.appendChild(Object.assign(document.createElement('div'), { textContent: `ラウンド${message.data.round}の賞はすべて決まりました` }));
+        } else if (message.type === 'kicked') {
+            roomPassword = ''; // 退出させられたルームには再接続しない
+            playerToken = '';
+            saveGameStateToSessionStorage();
+            alert(message.data.message); // 退出させられたことを表示
+        } else if (message.type === 'server_shutdown') {
+            serverShuttingDown = true;
+            logDiv.appendChild(Object.assign(document.createElement('div'), { textContent: message.data.message })); // サーバーの停止を表示
         } else if (message.message) {
             console.log('Received message:', message.message);
         } else {
@@ -211,7 +285,11 @@ function resetGame() {
     clearInterval(countdownInterval); // カウントダウンのインターバルをクリア
     countdownDiv.textContent = ''; // カウントダウン表示をクリア
     console.log('番号リセット');
-    fetch('/reset-generated-numbers') // 生成された数字をリセットするためのリクエストを送信
+    // ホストの場合は同じルームで次のラウンドを準備する
+    const resetUrl = hostToken
+        ? `/reset-generated-numbers?password=${encodeURIComponent(roomPassword)}&hostToken=${encodeURIComponent(hostToken)}`
+        : '/reset-generated-numbers';
+    fetch(resetUrl) // 生成された数字をリセットするためのリクエストを送信
         .then(handleResponse)
         .then(() => {
             generatedNumbers = [];
@@ -240,6 +318,8 @@ function joinRoom() {
     .then(data => {
         if (data.message) {
             console.log(data.message); // 成功メッセージをコンソールに表示
+            playerToken = data.playerToken || ''; // プレイヤー用トークンを保存
+            sendWebSocketJoin(); // WebSocketでもルームに参加する
             // パスワードが正しい場合の処理を追加
             fetchRoomNumbers(); // 成功した場合に、テキストファイルの情報を取得する処理を呼び出す
         }
@@ -264,8 +344,10 @@ function createRoom() {
         .then(response => response.json()) // レスポンスをJSON形式で解析
         .then(data => {
             if (data.password) {
+                roomPassword = data.password; // 作成したルームのパスワードを保存
+                hostToken = data.hostToken || ''; // ホスト用トークンを保存
                 console.log(`生成されたパスワード: ${data.password}`); // 生成されたパスワードを表示
-                alert(`生成されたパスワード: ${data.password}`); // ユーザーに生成されたパスワードを示すアラートを表示
+                alert(`生成されたパスワード: ${data.password}\n大画面表示: /board.html?code=${data.viewCode}`); // ユーザーに生成されたパスワードと閲覧用URLを示すアラートを表示
             } else {
                 alert('部屋のパスワードを生成できませんでした'); // パスワードが生成されなかった場合のエラーアラート
             }
@@ -287,19 +369,45 @@ function handleSetIntervalBtnClick() {
   row.style.display = 'block';
 
     // 新しいゲームの開始をサーバーに要求し、ビンゴカードをレンダリングする
-    fetch('/new-game')
+    // ルームに参加している場合はルームのカードを受け取る
+    const newGameUrl = playerToken
+        ? `/new-game?password=${encodeURIComponent(roomPassword)}&playerToken=${encodeURIComponent(playerToken)}`
+        : '/new-game';
+    fetch(newGameUrl)
         .then(response => response.json())
         .then(data => {
-            renderBingoCard(data); // ビンゴカードをレンダリングする
+            cardId = data.id || ''; // ルームのカードの場合はIDを保存する
+            cardIssuedAfter = data.issuedAfter || 0; // 途中で配られたカードは以降に引かれた数字だけが有効
+            roundPattern = data.pattern || roundPattern; // 現在の賞の形
+            renderBingoCard(data.card || data); // ビンゴカードをレンダリングする
             const interval = data.interval !== undefined ? data.interval : 1; // 取得したインターバルを設定し、デフォルト値は1
             startCountdown(interval); // カウントダウンを開始する
         })
         .catch(handleError); // エラーハンドリング
 
+    // ホストの場合はルームのゲームを開始する
+    if (hostToken) {
+        sendRoomAction('start');
+    }
+
     generateNumbersEnabled = true; // 数字の生成を有効にする
     // resetGame(); // ゲームをリセットする（コメントアウトされているが、必要に応じて使用する）
 }
 
+// ホストとしてルームを操作する関数（start, pause, resume, finish, restart, close）
+function sendRoomAction(action) {
+    return fetch('/room-control', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json'
+        },
+        body: JSON.stringify({ password: roomPassword, hostToken: hostToken, action: action })
+    })
+    .then(handleResponse)
+    .then(data => console.log('ルームの状態:', data.state))
+    .catch(handleError);
+}
+
 // 共通のエラーハンドラー関数
 function handleError(error) {
     console.error('Error:', error.message); // エラーメッセージをコンソールに出力する
@@ -310,58 +418,41 @@ let countdownInterval;
 let generatedNumbers = [];
 const audioPath = 'chime.mp3';
 
+// カードが配られた後に引かれた数字を返す関数
+function cardNumbers() {
+    return generatedNumbers.slice(cardIssuedAfter);
+}
+
 // セルがクリック可能かどうかを判断する関数
 function isClickableCell(cellValue) {
     return !generatedNumbers.includes(cellValue); // 生成された数字に含まれていなければクリック可能
 }
 
-// ルーム番号を取得する関数
+// ルーム番号を取得する関数（Server-Sent Eventsで新しい数字を受け取る）
+let roomNumbersSource;
 function fetchRoomNumbers() {
     console.log('Fetching room numbers...'); // ルーム番号の取得を開始するログメッセージ
 
-    const url = `/get-room-numbers?password=${encodeURIComponent(roomPassword)}`; // パスワードを含むURLを生成する
-
-    fetch(url)
-        .then(response => {
-            if (!response.ok) {
-                throw new Error(`Failed to fetch room numbers. Server returned ${response.status} ${response.statusText}`); // エラーメッセージを投げる
-            }
-            // レスポンスをストリームとして処理する
-            const reader = response.body.getReader();
+    if (roomNumbersSource) {
+        roomNumbersSource.close(); // 既存の接続を閉じる
+    }
 
-            // データを一つずつ処理する
-            let decoder = new TextDecoder();
-            let received = '';
+    const url = `/get-room-numbers?password=${encodeURIComponent(roomPassword)}`; // パスワードを含むURLを生成する
+    // 再接続時はブラウザがLast-Event-IDを送信するため、取りこぼした数字だけが届く
+    roomNumbersSource = new EventSource(url);
+
+    roomNumbersSource.addEventListener('draw', event => {
+        try {
+            const data = JSON.parse(event.data); // JSONデータを解析する
+            processReceivedData(data.number); // 受信したデータを処理する
+        } catch (error) {
+            console.error('Error parsing JSON:', error); // JSON解析エラーをコンソールに出力する
+        }
+    });
 
-            reader.read().then(function processText({ done, value }) {
-                if (done) {
-                    console.log('Received data:', received); // 受信したデータをログに出力する
-                    return;
-                }
-                
-                received += decoder.decode(value, { stream: true }); // 受信したデータをデコードする
-                
-                // データを改行で区切って分割する
-                let parts = received.split('\n');
-                parts.forEach(part => {
-                    if (part.trim() !== '') {
-                        try {
-                            const jsonData = JSON.parse(part); // JSONデータを解析する
-                            processReceivedData(jsonData); // 受信したデータを処理する
-                        } catch (error) {
-                            console.error('Error parsing JSON:', error); // JSON解析エラーをコンソールに出力する
-                            console.error('JSON parse error occurred in part:', part); // エラーが発生した部分の内容をコンソールに出力する
-                        }
-                    }
-                });
-                
-                return reader.read().then(processText); // 次のデータを処理する
-            });            
-        })
-        .catch(error => {
-            console.error('Error fetching room numbers:', error.message); // ルーム番号の取得中にエラーが発生した場合のエラーメッセージをコンソールに出力する
-            alert('Failed to fetch room numbers. Please try again later.'); // ユーザーにエラーメッセージをアラートで表示する
-        });
+    roomNumbersSource.onerror = function(error) {
+        console.error('Error fetching room numbers:', error); // 接続エラーをコンソールに出力する（ブラウザが自動で再接続する）
+    };
 }
 // 受信したデータを処理する関数
 function processReceivedData(data) {
@@ -465,7 +556,7 @@ function enableClickableCells() {
     }
     cells.forEach(cell => {
         const cellNumber = cell.textContent === 'FREE' ? 0 : parseInt(cell.textContent); // セルの内容を数値に変換する（'FREE'の場合は0）
-        if (Array.isArray(generatedNumbers) && (generatedNumbers.includes(cellNumber) || cellNumber === 0)) {
+        if (Array.isArray(generatedNumbers) && (cardNumbers().includes(cellNumber) || cellNumber === 0)) {
             // 生成された数字の配列に含まれているか、セルの数字が0（FREEセル）の場合
             if (!cell.classList.contains('clickable')) {
                 cell.classList.add('clickable'); // 'clickable'クラスを追加してセルをクリック可能にする
@@ -524,6 +615,17 @@ function renderBingoCard(data) {
     enableClickableCells(); // クリック可能なセルを有効にする
 }
 
+// サーバーから受け取ったマーク状態をカードに反映する関数
+function applyMarks(marked) {
+    const cells = bingoCard.querySelectorAll('.cell');
+    marked.forEach((row, i) => {
+        row.forEach((isMarked, j) => {
+            window.marked[i][j] = isMarked;
+            cells[i * 5 + j].classList.toggle('marked', isMarked);
+        });
+    });
+}
+
 // セルをマークする関数
 function markCell(cellElement) {
     if (!cellElement || !cellElement.textContent) {
@@ -531,7 +633,7 @@ function markCell(cellElement) {
     }
 
     const value = parseInt(cellElement.textContent);
-    if (isNaN(value) || generatedNumbers.indexOf(value) === -1) {
+    if (isNaN(value) || cardNumbers().indexOf(value) === -1) {
         return; // 数値に変換できない場合や、生成された数字のリストに含まれていない場合は処理しない
     }
 
@@ -547,22 +649,60 @@ function markCell(cellElement) {
 
 // ビンゴをチェックする関数
 function checkBingo() {
+    // ルームでは外れた申告がお手つきになるため、手元で現在の賞の形が揃った場合のみ申告する
+    if (playerToken && !matchesPattern(window.marked, roundPattern)) {
+        return;
+    }
     fetch('/check-bingo', {
         method: 'POST',
         headers: {
             'Content-Type': 'application/json'
         },
-        body: JSON.stringify({ marked: window.marked }) // マークされたセルの状態をサーバーに送信する
+        body: JSON.stringify({
+            password: playerToken ? roomPassword : '', // ルームに参加している場合はルームで申告する
+            playerToken: playerToken,
+            cardId: cardId,
+            marked: window.marked // マークされたセルの状態をサーバーに送信する
+        })
     })
     .then(handleResponse) // レスポンスを処理する
     .then(data => {
-        if (data.bingo) {
+        if (data.pending) {
+            alert('申告を受け付けました。同時の申告の締め切り後に結果をお知らせします');
+        } else if (data.bingo) {
             alert('ビンゴです！'); // サーバーからのレスポンスでビンゴが成立している場合にアラートを表示する
         }
     })
     .catch(handleError); // エラーが発生した場合にエラーハンドラーを実行する
 }
 
+// マーク状態が勝ちとなる形を満たしているかを確認する関数（判定はサーバーでも行う）
+function matchesPattern(marked, pattern) {
+    const lines = [];
+    for (let i = 0; i < 5; i++) {
+        lines.push([0, 1, 2, 3, 4].map(j => marked[i][j])); // 横
+        lines.push([0, 1, 2, 3, 4].map(j => marked[j][i])); // 縦
+    }
+    const down = [0, 1, 2, 3, 4].map(i => marked[i][i]).every(Boolean); // 斜め（右下がり）
+    const up = [0, 1, 2, 3, 4].map(i => marked[i][4 - i]).every(Boolean); // 斜め（右上がり）
+    const complete = lines.filter(line => line.every(Boolean)).length + (down ? 1 : 0) + (up ? 1 : 0);
+
+    switch (pattern) {
+        case 'line':
+            return complete >= 1;
+        case 'two-lines':
+            return complete >= 2;
+        case 'four-corners':
+            return marked[0][0] && marked[0][4] && marked[4][0] && marked[4][4];
+        case 'x':
+            return down && up;
+        case 'blackout':
+            return marked.every(row => row.every(Boolean));
+        default:
+            return true; // 知らない形はサーバーの判定に任せる
+    }
+}
+
 // セルのフォントサイズを調整する関数
 function adjustFontSize(cell) {
     const cellSize = Math.min(cell.offsetWidth, cell.offsetHeight); // セルの幅と高さの小さい方を取得する
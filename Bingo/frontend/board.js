@@ -0,0 +1,108 @@
+// 大画面表示（閲覧専用）のスクリプト
+// URLの ?code=閲覧用コード でルームを指定する
+
+const boardParams = new URLSearchParams(window.location.search);
+const viewCode = boardParams.get('code') || ''; // 閲覧専用コード
+const recentCount = boardParams.get('recent') || 5; // 直近に表示する数字の件数
+
+// 必要な要素を取得
+const statusDiv = document.getElementById('board-status'); // 状態表示用要素
+const currentLetterDiv = document.getElementById('board-current-letter'); // 現在の文字表示用要素
+const currentDiv = document.getElementById('board-current'); // 現在の数字表示用要素
+const boardCountdownDiv = document.getElementById('board-countdown'); // カウントダウン表示用要素
+const recentDiv = document.getElementById('board-recent'); // 直近の数字表示用要素
+const gridDiv = document.getElementById('board-grid'); // 75個の数字の表示用要素
+
+let boardCountdownInterval; // カウントダウンのタイマー
+
+// DOMのロード完了後に実行される初期化関数
+document.addEventListener('DOMContentLoaded', function() {
+    if (!viewCode) {
+        statusDiv.textContent = '閲覧用コードを指定してください（board.html?code=...）';
+        return;
+    }
+    connectBoardFeed();
+});
+
+// 大画面表示用のフィードに接続する関数
+function connectBoardFeed() {
+    const url = `/board-feed?code=${encodeURIComponent(viewCode)}&recent=${encodeURIComponent(recentCount)}`;
+    const source = new EventSource(url); // 切断時はブラウザが自動で再接続する
+
+    source.addEventListener('board', event => {
+        try {
+            renderBoard(JSON.parse(event.data)); // 受信した状態で表示を更新する
+        } catch (error) {
+            console.error('Error parsing board data:', error);
+        }
+    });
+
+    source.onerror = function(error) {
+        console.error('Board feed error:', error);
+        statusDiv.textContent = '再接続しています...';
+    };
+}
+
+// ルームの状態を表示する関数
+function renderBoard(board) {
+    statusDiv.textContent = `${board.total} / 75`;
+    if (board.reach > 0) {
+        statusDiv.textContent += `　リーチ ${board.reach}人`; // リーチになっている人数を表示
+    }
+
+    // 現在の数字を大きく表示する
+    if (board.current) {
+        currentLetterDiv.textContent = board.current.letter;
+        currentDiv.textContent = board.current.number;
+    } else {
+        currentLetterDiv.textContent = '';
+        currentDiv.textContent = '-';
+    }
+
+    // 直近の数字を表示する（現在の数字は除く）
+    recentDiv.innerHTML = '';
+    board.recent.slice(1).forEach(draw => {
+        const item = document.createElement('span');
+        item.className = 'board-recent-item';
+        item.textContent = `${draw.letter}${draw.number}`;
+        recentDiv.appendChild(item);
+    });
+
+    // 75個の数字の表を表示する（引かれた数字は点灯させる）
+    gridDiv.innerHTML = '';
+    board.board.forEach(column => {
+        const row = document.createElement('div');
+        row.className = 'board-row';
+
+        const letter = document.createElement('div');
+        letter.className = 'board-letter';
+        letter.textContent = column.letter;
+        row.appendChild(letter);
+
+        column.numbers.forEach(cell => {
+            const item = document.createElement('div');
+            item.className = cell.called ? 'board-cell called' : 'board-cell';
+            item.textContent = cell.number;
+            row.appendChild(item);
+        });
+        gridDiv.appendChild(row);
+    });
+
+    startBoardCountdown(board);
+}
+
+// 次の数字までのカウントダウンを表示する関数
+function startBoardCountdown(board) {
+    clearInterval(boardCountdownInterval);
+    if (board.state !== 'running') {
+        boardCountdownDiv.textContent = board.state === 'paused' ? '一時停止中' : '';
+        return;
+    }
+
+    let remaining = board.countdown;
+    boardCountdownDiv.textContent = remaining;
+    boardCountdownInterval = setInterval(() => {
+        remaining = Math.max(remaining - 1, 0);
+        boardCountdownDiv.textContent = remaining;
+    }, 1000); // 1秒ごとに更新する
+}
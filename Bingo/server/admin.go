@@ -0,0 +1,203 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MinAdminTokenLength 管理用トークンの最短の長さ
+const MinAdminTokenLength = 16
+
+// AdminRoomSummary 管理APIのルーム一覧の一件分
+type AdminRoomSummary struct {
+	ID           string    `json:"id"`           // ルームID
+	State        RoomState `json:"state"`        // ルームの状態
+	Players      int       `json:"players"`      // 参加しているプレイヤーの数
+	Clients      int       `json:"clients"`      // 接続中のWebSocketクライアントの数
+	Interval     int       `json:"interval"`     // インターバル（秒）
+	Round        int       `json:"round"`        // 現在のラウンド番号
+	Draws        int       `json:"draws"`        // 現在のラウンドで引かれた数字の数
+	CreatedAt    time.Time `json:"createdAt"`    // 作成時刻
+	AgeSeconds   int       `json:"ageSeconds"`   // 作成からの経過時間（秒）
+	LastActivity time.Time `json:"lastActivity"` // 最後に操作や参加があった時刻
+}
+
+// AdminRoomDetail 管理APIのルーム詳細
+type AdminRoomDetail struct {
+	AdminRoomSummary
+	Roster []RosterEntry `json:"roster"` // 参加者一覧
+	Rounds []Round       `json:"rounds"` // ラウンドごとの引かれた数字と勝者
+}
+
+// GetRoomByID ルームIDに基づいてルームを取得する関数
+func (rm *RoomManager) GetRoomByID(id string) *Room {
+	rm.Mutex.Lock()
+	defer rm.Mutex.Unlock()
+
+	if id == "" {
+		return nil
+	}
+	return rm.roomByIDLocked(id)
+}
+
+// roomByIDLocked ルームIDに基づいてルームを取得する（rm.Mutexを保持して呼び出すこと）
+func (rm *RoomManager) roomByIDLocked(id string) *Room {
+	for _, room := range rm.Rooms {
+		if room.ID == id {
+			return room
+		}
+	}
+	return nil
+}
+
+// AdminSummary 管理API向けにルームの概要をまとめる
+func (room *Room) AdminSummary() AdminRoomSummary {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	return room.adminSummaryLocked()
+}
+
+// adminSummaryLocked 管理API向けにルームの概要をまとめる（room.Mutexを保持して呼び出すこと）
+func (room *Room) adminSummaryLocked() AdminRoomSummary {
+	return AdminRoomSummary{
+		ID:           room.ID,
+		State:        room.State,
+		Players:      len(room.Players),
+		Clients:      len(room.Clients),
+		Interval:     room.Interval,
+		Round:        room.Round.Number,
+		Draws:        len(room.Round.Game.Draws()),
+		CreatedAt:    room.CreatedAt,
+		AgeSeconds:   int(room.now().Sub(room.CreatedAt).Seconds()),
+		LastActivity: room.LastActivity,
+	}
+}
+
+// adminOnly 管理用トークンで認証されたリクエストだけを通す
+// トークンは "Authorization: Bearer <トークン>" で受け取る。設定されていない場合は管理APIを無効にする
+func (s *Server) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.AdminToken == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		ip := s.clientIP(r)
+		if ok, retryAfter := s.limiter.AllowIP(ip); !ok {
+			writeRateLimited(w, retryAfter)
+			return
+		}
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) != 1 {
+			s.limiter.RecordFailure(ip)
+			s.requestLogger(r).Warn("管理APIの認証に失敗しました", LogKeyEvent, "admin_auth_failed", "ip", ip)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="bingo-admin"`)
+			http.Error(w, "管理用トークンが無効です", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// すべてのルームの概要を返すハンドラー関数（管理用）
+func (s *Server) AdminRoomsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "無効なHTTPメソッド", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rooms := s.rooms.ListRooms()
+	summaries := make([]AdminRoomSummary, 0, len(rooms))
+	for _, room := range rooms {
+		summaries = append(summaries, room.AdminSummary())
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].CreatedAt.Before(summaries[j].CreatedAt) })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"rooms": summaries})
+}
+
+// ルームの引かれた数字と勝者を含む詳細を返すハンドラー関数（管理用）
+func (s *Server) AdminRoomHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "無効なHTTPメソッド", http.StatusMethodNotAllowed)
+		return
+	}
+
+	room := s.rooms.GetRoomByID(r.URL.Query().Get("id"))
+	if room == nil {
+		http.Error(w, "ルームが見つかりませんでした", http.StatusNotFound)
+		return
+	}
+
+	detail := AdminRoomDetail{
+		AdminRoomSummary: room.AdminSummary(),
+		Roster:           room.Roster(),
+		Rounds:           room.RoundHistory(0),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// ルームを強制的に閉じるハンドラー関数（管理用）
+func (s *Server) AdminCloseRoomHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "無効なHTTPメソッド", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID string `json:"id"` // ルームID
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "リクエスト本文が無効です", http.StatusBadRequest)
+		return
+	}
+
+	room := s.rooms.GetRoomByID(req.ID)
+	if room == nil || !s.rooms.CloseRoom(room.Password, "admin") {
+		http.Error(w, "ルームが見つかりませんでした", http.StatusNotFound)
+		return
+	}
+	s.scheduler.Wake() // 抽選のスケジュールを再計算する
+	s.requestLogger(r).Info("管理APIでルームを閉じました", LogKeyEvent, "admin_close_room", LogKeyRoom, room.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"id": room.ID, "state": string(RoomClosed)})
+}
+
+// プレイヤーをルームから退出させるハンドラー関数（管理用）
+func (s *Server) AdminKickHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "無効なHTTPメソッド", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID       string `json:"id"`       // ルームID
+		PlayerID string `json:"playerId"` // 退出させるプレイヤーID
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "リクエスト本文が無効です", http.StatusBadRequest)
+		return
+	}
+
+	room := s.rooms.GetRoomByID(req.ID)
+	if room == nil {
+		http.Error(w, "ルームが見つかりませんでした", http.StatusNotFound)
+		return
+	}
+	if !room.KickPlayer(req.PlayerID) {
+		http.Error(w, "プレイヤーが見つかりませんでした", http.StatusNotFound)
+		return
+	}
+	s.requestLogger(r).Info("管理APIでプレイヤーを退出させました", LogKeyEvent, "admin_kick", LogKeyRoom, room.ID, LogKeyPlayer, req.PlayerID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"id": room.ID, "playerId": req.PlayerID})
+}
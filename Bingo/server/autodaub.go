@@ -0,0 +1,84 @@
+package server
+
+import (
+	"errors"
+
+	"bingo/bingo"
+)
+
+// DaubEvent 自動マークでカードのマーク状態が変わったことの通知（カードを持つプレイヤーにのみ送る）
+type DaubEvent struct {
+	Round  int         `json:"round"`  // ラウンド番号
+	CardID string      `json:"cardId"` // マークされたカードのID
+	Number int         `json:"number"` // マークされた数字
+	Marked bingo.Marks `json:"marked"` // カード全体のマーク状態
+}
+
+// sendToPlayerLocked プレイヤーの接続すべてにイベントを送る（room.Mutexを保持して呼び出すこと）
+func (room *Room) sendToPlayerLocked(playerID string, ev RoomEvent) {
+	for _, client := range room.Clients {
+		if client.Role == RolePlayer && client.PlayerID == playerID {
+			client.send(ev)
+		}
+	}
+}
+
+// sendToHostsLocked ホストとして接続しているクライアントすべてにイベントを送る（room.Mutexを保持して呼び出すこと）
+func (room *Room) sendToHostsLocked(ev RoomEvent) {
+	for _, client := range room.Clients {
+		if client.Host {
+			client.send(ev)
+		}
+	}
+}
+
+// autoDaubLocked 引かれた数字を配られたすべてのカードに自動でマークする（room.Mutexを保持して呼び出すこと）
+// 自動申告が有効な場合は、ビンゴになったカードを参加順に申告する
+func (room *Room) autoDaubLocked(number int) {
+	if !room.AutoDaub {
+		return
+	}
+
+	round := room.Round
+	for _, entry := range room.rosterLocked() {
+		player := room.Players[entry.ID]
+		for _, card := range round.Cards[entry.ID] {
+			if card.Disqualified || !card.Card.Contains(number) {
+				continue
+			}
+			room.sendToPlayerLocked(player.ID, RoomEvent{Type: "daub", Data: DaubEvent{
+				Round:  round.Number,
+				CardID: card.ID,
+				Number: number,
+				Marked: round.checkerFor(card).Marks(card.Card),
+			}})
+
+			if !room.AutoClaim || !room.autoClaimableLocked(card) {
+				continue
+			}
+			// 手動の申告と同じ制限（待ち時間・失格）と履歴を通す
+			_, _, err := room.claimCardLocked(player, card)
+			var cooldown *ClaimCooldownError
+			if err != nil && !errors.Is(err, ErrPrizesAwarded) && !errors.Is(err, ErrCardDisqualified) && !errors.As(err, &cooldown) {
+				room.logger().Warn("自動申告に失敗しました", LogKeyEvent, "auto_claim_failed", LogKeyPlayer, player.ID, "card", card.ID, "error", err)
+			}
+		}
+	}
+}
+
+// autoClaimableLocked カードが現在の賞の形を満たしていて、まだ申告されていないかを返す（room.Mutexを保持して呼び出すこと）
+// 揃っていないカードを自動で申告してお手つきにしないために使う
+func (room *Room) autoClaimableLocked(card *IssuedCard) bool {
+	round := room.Round
+	prize := round.CurrentPrize()
+	if prize == nil || round.pending.has(card.ID) {
+		return false
+	}
+	for _, win := range round.Winners {
+		if win.CardID == card.ID && win.Stage == round.Stage {
+			return false // 既に現在の賞を得ている
+		}
+	}
+	_, ok := round.checkerFor(card).Check(card.Card, prize.Pattern)
+	return ok
+}
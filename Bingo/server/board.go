@@ -0,0 +1,182 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bingo/bingo"
+)
+
+// 大画面表示で直近に表示する数字の件数
+const BoardDefaultRecent = 5
+
+// BoardSnapshot 大画面表示用のルームの状態
+type BoardSnapshot struct {
+	State     RoomState     `json:"state"`     // ルームの状態
+	Round     int           `json:"round"`     // 現在のラウンド番号
+	Pattern   bingo.Pattern `json:"pattern"`   // 現在の賞の形
+	Prize     string        `json:"prize"`     // 現在の賞の名前（すべて決まっている場合は空）
+	Current   *bingo.Draw   `json:"current"`   // 最後に引かれた数字（まだなければnull）
+	Recent    []bingo.Draw  `json:"recent"`    // 直近に引かれた数字（新しい順）
+	Board     []BoardColumn `json:"board"`     // 75個の数字の表
+	Total     int           `json:"total"`     // これまでに引かれた数字の数
+	Reach     int           `json:"reach"`     // リーチになっているプレイヤーの数
+	Interval  int           `json:"interval"`  // 数字を引く間隔（秒）
+	Countdown int           `json:"countdown"` // 次の数字までの残り時間（秒）
+}
+
+// BoardColumn B-I-N-G-Oの列ごとの数字
+type BoardColumn struct {
+	Letter  string      `json:"letter"`  // 列の文字
+	Numbers []BoardCell `json:"numbers"` // 列に含まれる15個の数字
+}
+
+// BoardCell 表の一つの数字と、既に引かれたかどうか
+type BoardCell struct {
+	Number int  `json:"number"` // 数字
+	Called bool `json:"called"` // 既に引かれているか
+}
+
+// GetRoomByViewCode 閲覧専用コードに基づいてルームを取得する関数
+func (rm *RoomManager) GetRoomByViewCode(code string) *Room {
+	rm.Mutex.Lock()
+	defer rm.Mutex.Unlock()
+
+	return rm.ViewCodes[code]
+}
+
+// BoardSnapshot 大画面表示用にルームの状態をまとめる
+func (room *Room) BoardSnapshot(recent int) BoardSnapshot {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	game := room.Round.Game
+	draws := game.Draws()
+	snapshot := BoardSnapshot{
+		State:     room.State,
+		Round:     room.Round.Number,
+		Pattern:   game.Pattern,
+		Recent:    []bingo.Draw{},
+		Total:     len(draws),
+		Reach:     room.reachPlayersLocked(),
+		Interval:  room.Interval,
+		Countdown: room.Countdown,
+	}
+	if prize := room.Round.CurrentPrize(); prize != nil {
+		snapshot.Prize = prize.Name
+	}
+	if current, ok := game.Last(); ok {
+		snapshot.Current = &current
+	}
+	for i := len(draws) - 1; i >= 0 && len(snapshot.Recent) < recent; i-- {
+		snapshot.Recent = append(snapshot.Recent, draws[i])
+	}
+
+	for col := 0; col < 5; col++ {
+		column := BoardColumn{Letter: bingo.Letter(col*15 + 1)}
+		for n := col*15 + 1; n <= col*15+15; n++ {
+			column.Numbers = append(column.Numbers, BoardCell{Number: n, Called: game.Checker().Called(n)})
+		}
+		snapshot.Board = append(snapshot.Board, column)
+	}
+
+	return snapshot
+}
+
+// 閲覧専用コードと表示件数をリクエストから読み取る関数
+func (s *Server) boardRequest(w http.ResponseWriter, r *http.Request) (*Room, int) {
+	query := r.URL.Query()
+	code := query.Get("code")
+	if code == "" {
+		http.Error(w, "閲覧用コードが提供されていません", http.StatusBadRequest)
+		return nil, 0
+	}
+
+	recent := BoardDefaultRecent
+	if v := query.Get("recent"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "recentは0以上の整数で指定してください", http.StatusBadRequest)
+			return nil, 0
+		}
+		recent = min(n, bingo.MaxNumber)
+	}
+
+	room := s.lookupRoomBy(w, r, code, s.rooms.GetRoomByViewCode)
+	return room, recent
+}
+
+// 大画面表示用のルームの状態をJSONで返すハンドラー関数
+func (s *Server) BoardHandler(w http.ResponseWriter, r *http.Request) {
+	room, recent := s.boardRequest(w, r)
+	if room == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(room.BoardSnapshot(recent))
+}
+
+// 大画面表示用のルームの状態をServer-Sent Eventsで配信するハンドラー関数
+// ルームでイベントが起きるたびに最新の状態全体を送信する
+func (s *Server) BoardFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if s.rejectIfDraining(w) {
+		return // 停止処理中は新しい接続を受け付けない
+	}
+	room, recent := s.boardRequest(w, r)
+	if room == nil {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "ストリーミングに対応していません", http.StatusInternalServerError)
+		return
+	}
+
+	// 状態全体を送るため、過去の抽選を再送する必要はない
+	events, _ := room.Subscribe("")
+	defer room.Unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // リバースプロキシでのバッファリングを無効化
+	w.WriteHeader(http.StatusOK)
+
+	send := func() error {
+		snapshot := room.BoardSnapshot(recent)
+		if err := writeSSE(s.requestLogger(r).With(LogKeyRoom, room.ID), w, RoomEvent{Type: "board", Data: snapshot}); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	if err := send(); err != nil {
+		return
+	}
+
+	// 接続を維持するために定期的に状態を送信する（カウントダウンの補正も兼ねる）
+	keepAlive := s.rooms.clock.NewTicker(SSEKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				s.requestLogger(r).Info("大画面表示の配信を終了しました", LogKeyEvent, "board_feed_closed", LogKeyRoom, room.ID)
+				return
+			}
+			if err := send(); err != nil {
+				return
+			}
+		case <-keepAlive.C():
+			if err := send(); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return // クライアントが切断した
+		}
+	}
+}
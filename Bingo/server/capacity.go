@@ -0,0 +1,108 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// 容量の既定の上限
+const (
+	MaxRooms          = 1000 // サーバー全体のルーム数の既定の上限
+	MaxRoomsPerIP     = 5    // 一つのIPが同時に持てるルーム数の既定の上限
+	MaxClientsPerRoom = 200  // 一つのルームに同時に接続できるクライアント数の既定の上限
+	MaxPlayersPerRoom = 500  // 一つのルームに登録できるプレイヤー数の既定の上限
+	MaxCardsPerPlayer = 4    // 一人のプレイヤーに一つのラウンドで配るカード数の既定の上限
+)
+
+// CapacityError 容量の上限に達したことを表すエラー
+type CapacityError struct {
+	Code    string // クライアントが判別するためのエラーコード
+	Status  int    // 返すHTTPステータスコード
+	Message string // 表示用のメッセージ
+}
+
+// Error エラーメッセージを返す
+func (e *CapacityError) Error() string {
+	return e.Message
+}
+
+// 容量の上限に関するエラー
+var (
+	ErrTooManyRooms      = &CapacityError{Code: "room_limit", Status: http.StatusServiceUnavailable, Message: "サーバーのルーム数が上限に達しています"}
+	ErrTooManyRoomsForIP = &CapacityError{Code: "ip_room_limit", Status: http.StatusTooManyRequests, Message: "作成できるルーム数の上限に達しています"}
+	ErrRoomFull          = &CapacityError{Code: "room_full", Status: http.StatusConflict, Message: "ルームの接続数が上限に達しています"}
+	ErrTooManyPlayers    = &CapacityError{Code: "player_limit", Status: http.StatusConflict, Message: "ルームの参加者数が上限に達しています"}
+	ErrTooManyCards      = &CapacityError{Code: "card_limit", Status: http.StatusConflict, Message: "このラウンドで受け取れるカードの上限に達しています"}
+)
+
+// roomsForIPLocked IPが作成したルームの数を返す（rm.Mutexを保持して呼び出すこと）
+func (rm *RoomManager) roomsForIPLocked(ip string) int {
+	count := 0
+	for _, room := range rm.Rooms {
+		if room.creatorIP == ip {
+			count++
+		}
+	}
+	return count
+}
+
+// checkRoomCapacityLocked 新しいルームを作成できるかを確認する（rm.Mutexを保持して呼び出すこと）
+func (rm *RoomManager) checkRoomCapacityLocked(ip string) error {
+	if len(rm.Rooms) >= rm.config.Limits.MaxRooms {
+		return ErrTooManyRooms
+	}
+	if ip != "" && rm.roomsForIPLocked(ip) >= rm.config.Limits.MaxRoomsPerIP {
+		return ErrTooManyRoomsForIP
+	}
+	return nil
+}
+
+// CheckClientCapacity ルームに新しいクライアントが接続できるかを確認する
+func (room *Room) CheckClientCapacity() error {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	return room.checkClientCapacityLocked()
+}
+
+// checkClientCapacityLocked ルームに新しいクライアントが接続できるかを確認する（room.Mutexを保持して呼び出すこと）
+func (room *Room) checkClientCapacityLocked() error {
+	if len(room.Clients) >= room.manager.config.Limits.MaxClientsPerRoom {
+		return ErrRoomFull
+	}
+	return nil
+}
+
+// checkPlayerCapacityLocked ルームに新しいプレイヤーを登録できるかを確認する（room.Mutexを保持して呼び出すこと）
+func (room *Room) checkPlayerCapacityLocked() error {
+	if len(room.Players) >= room.manager.config.Limits.MaxPlayersPerRoom {
+		return ErrTooManyPlayers
+	}
+	return nil
+}
+
+// writeCapacityError 容量の上限に達したことをエラーコード付きのJSONで返す関数
+// 容量以外のエラーは500として返す
+func writeCapacityError(w http.ResponseWriter, err error) {
+	var capacity *CapacityError
+	if !errors.As(err, &capacity) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(capacity.Status)
+	json.NewEncoder(w).Encode(map[string]string{"error": capacity.Message, "code": capacity.Code})
+}
+
+// writeCapacityJSON 容量の上限に達したことをWebSocketでエラーコード付きで通知する関数
+func writeCapacityJSON(conn *websocket.Conn, err error) {
+	var capacity *CapacityError
+	if !errors.As(err, &capacity) {
+		conn.WriteJSON(map[string]string{"error": err.Error()})
+		return
+	}
+	conn.WriteJSON(map[string]string{"error": capacity.Message, "code": capacity.Code})
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestJoinsCountOnlyAdmittedPlayers(t *testing.T) {
+	s, _, ts := newTestServer(t, func(cfg *Config) { cfg.Limits.MaxPlayersPerRoom = 2 })
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	// WebSocketでルームを作成したクライアントもプレイヤーとして数える
+	host, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer host.Close()
+	host.WriteJSON(map[string]string{"name": "ホスト"})
+	var created map[string]interface{}
+	if err := host.ReadJSON(&created); err != nil {
+		t.Fatal(err)
+	}
+	password := created["roomPassword"].(string)
+
+	if status, resp := postJSON(t, ts.URL+"/join-room", map[string]string{"password": password}); status != http.StatusOK {
+		t.Fatalf("ルームへの参加: ステータス %d, %v", status, resp)
+	}
+
+	// 上限を超えた参加はHTTPでもWebSocketでも数えない
+	if status, resp := postJSON(t, ts.URL+"/join-room", map[string]string{"password": password}); status != http.StatusConflict || resp["code"] != "player_limit" {
+		t.Fatalf("上限を超えた参加: ステータス %d, %v", status, resp)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.WriteJSON(map[string]string{"password": password})
+	var rejected map[string]interface{}
+	if err := conn.ReadJSON(&rejected); err != nil || rejected["code"] != "player_limit" {
+		t.Fatalf("WebSocketでの上限を超えた参加: %v, %v", rejected, err)
+	}
+
+	if got := s.metrics.Joins.Value(); got != 2 {
+		t.Fatalf("Joins = %d, want 2", got)
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FalseClaimPolicy ビンゴになっていない申告（お手つき）への対応
+type FalseClaimPolicy string
+
+// お手つきへの対応の一覧
+const (
+	FalseClaimWarn       FalseClaimPolicy = "warn"       // 警告のみ
+	FalseClaimCooldown   FalseClaimPolicy = "cooldown"   // 一定時間申告できなくする
+	FalseClaimDisqualify FalseClaimPolicy = "disqualify" // そのカードを失格にする
+)
+
+// お手つきに関する定数
+const (
+	DefaultFalseClaimCooldown = 30 // 申告できなくする時間の既定値（秒）
+	FalseClaimAlertThreshold  = 3  // ホストに通知するお手つきの回数（この回数ごとに通知する）
+)
+
+// 申告に関するエラー
+var (
+	ErrCardDisqualified = errors.New("このカードは失格になっています")
+)
+
+// ClaimCooldownError 申告の待ち時間中であることを表すエラー
+type ClaimCooldownError struct {
+	RetryAfter time.Duration // 再び申告できるまでの時間
+}
+
+func (e *ClaimCooldownError) Error() string {
+	return fmt.Sprintf("お手つきのため%d秒間は申告できません", int(e.RetryAfter.Round(time.Second).Seconds()))
+}
+
+// ClaimRecord ルームの履歴に残す申告の記録
+type ClaimRecord struct {
+	PlayerID   string           `json:"playerId"`             // 申告したプレイヤーのID
+	PlayerName string           `json:"playerName"`           // 申告したプレイヤーの表示名
+	CardID     string           `json:"cardId"`               // 申告されたカードのID
+	Ordinal    int              `json:"ordinal"`              // 何番目の抽選の後に申告されたか
+	Valid      bool             `json:"valid"`                // ビンゴが確認できたか
+	Action     FalseClaimPolicy `json:"action,omitempty"`     // お手つきへの対応
+	Cooldown   int              `json:"cooldown,omitempty"`   // 申告できなくした時間（秒）
+	FalseCount int              `json:"falseCount,omitempty"` // このラウンドでのプレイヤーのお手つきの回数
+	Time       time.Time        `json:"time"`                 // 申告された時刻
+}
+
+// Valid 対応しているお手つきへの対応かどうかを返す
+func (p FalseClaimPolicy) Valid() bool {
+	switch p {
+	case FalseClaimWarn, FalseClaimCooldown, FalseClaimDisqualify:
+		return true
+	}
+	return false
+}
+
+// checkClaimAllowedLocked プレイヤーとカードが申告できる状態かを確認する（room.Mutexを保持して呼び出すこと）
+func (room *Room) checkClaimAllowedLocked(player *Player, card *IssuedCard, now time.Time) error {
+	if now.Before(player.CooldownUntil) {
+		return &ClaimCooldownError{RetryAfter: player.CooldownUntil.Sub(now)}
+	}
+	if card.Disqualified {
+		return ErrCardDisqualified
+	}
+	return nil
+}
+
+// recordClaimLocked 申告をラウンドの履歴に残す（room.Mutexを保持して呼び出すこと）
+func (room *Room) recordClaimLocked(player *Player, card *IssuedCard, valid bool) *ClaimRecord {
+	round := room.Round
+	round.Claims = append(round.Claims, ClaimRecord{
+		PlayerID:   player.ID,
+		PlayerName: player.Name,
+		CardID:     card.ID,
+		Ordinal:    len(round.Game.Draws()),
+		Valid:      valid,
+		Time:       room.now(),
+	})
+	return &round.Claims[len(round.Claims)-1]
+}
+
+// falseClaimLocked お手つきをルームの設定に従って処理する（room.Mutexを保持して呼び出すこと）
+// 処理した内容を記録として返す
+func (room *Room) falseClaimLocked(player *Player, card *IssuedCard) ClaimRecord {
+	round := room.Round
+	record := room.recordClaimLocked(player, card, false)
+
+	round.FalseClaims[player.ID]++
+	record.FalseCount = round.FalseClaims[player.ID]
+	record.Action = room.FalseClaimPolicy
+
+	switch room.FalseClaimPolicy {
+	case FalseClaimCooldown:
+		player.CooldownUntil = record.Time.Add(time.Duration(room.FalseClaimCooldown) * time.Second)
+		record.Cooldown = room.FalseClaimCooldown
+	case FalseClaimDisqualify:
+		card.Disqualified = true
+		delete(round.Reach, card.ID) // 失格になったカードはリーチから外す
+	}
+
+	// 本人に対応を知らせる
+	room.sendToPlayerLocked(player.ID, RoomEvent{Type: "claim_rejected", Data: *record})
+
+	// 繰り返しお手つきをしたプレイヤーをホストに知らせる
+	if record.FalseCount%FalseClaimAlertThreshold == 0 {
+		room.sendToHostsLocked(RoomEvent{Type: "false_claim_alert", Data: *record})
+	}
+
+	room.logger().Info("お手つきを記録しました", LogKeyEvent, "false_claim", LogKeyPlayer, player.ID, "round", round.Number, "action", record.Action, "count", record.FalseCount)
+	return *record
+}
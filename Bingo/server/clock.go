@@ -0,0 +1,197 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock 現在時刻とタイマーを提供する時計
+// テストなどで時刻を差し替えられるようにServerに渡す
+type Clock interface {
+	Now() time.Time                            // 現在時刻を返す
+	NewTicker(d time.Duration) Ticker          // 一定間隔で時刻を送るティッカーを作成する
+	NewTimer(d time.Duration) Timer            // 一定時間後に時刻を送るタイマーを作成する
+	AfterFunc(d time.Duration, f func()) Timer // 一定時間後に関数を別のゴルーチンで呼び出す
+}
+
+// Ticker 一定間隔で時刻を送るティッカー
+type Ticker interface {
+	C() <-chan time.Time // 時刻を受け取るチャネル
+	Stop()               // ティッカーを停止する
+}
+
+// Timer 一定時間後に時刻を送る（または関数を呼び出す）タイマー
+type Timer interface {
+	C() <-chan time.Time // 時刻を受け取るチャネル（AfterFuncの場合はnil）
+	Stop() bool          // タイマーを停止する（既に発火・停止済みの場合はfalse）
+}
+
+// SystemClock システムの時刻を返す時計
+type SystemClock struct{}
+
+// Now 現在時刻を返す
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTicker time.Tickerを作成する
+func (SystemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+// NewTimer time.Timerを作成する
+func (SystemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+// AfterFunc time.AfterFuncでタイマーを作成する
+func (SystemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{time.AfterFunc(d, f)}
+}
+
+// systemTicker time.TickerをTickerとして扱うためのラッパー
+type systemTicker struct{ t *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+func (t systemTicker) Stop()               { t.t.Stop() }
+
+// systemTimer time.TimerをTimerとして扱うためのラッパー
+type systemTimer struct{ t *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time { return t.t.C }
+func (t systemTimer) Stop() bool          { return t.t.Stop() }
+
+// FakeClock構造体 Advanceを呼んだときだけ進む時計（テスト用）
+// ティッカーやタイマーは進めた時刻に達した順に発火する
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter // 発火を待っているティッカー・タイマー
+}
+
+// fakeWaiter FakeClockで発火を待っているティッカー・タイマー
+type fakeWaiter struct {
+	clock  *FakeClock
+	when   time.Time      // 次に発火する時刻
+	period time.Duration  // ティッカーの間隔（タイマーの場合は0）
+	ch     chan time.Time // 時刻を送るチャネル
+	fn     func()         // AfterFuncで呼び出す関数
+}
+
+// 新しいFakeClockインスタンスを作成
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now 現在時刻を返す
+func (fc *FakeClock) Now() time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	return fc.now
+}
+
+// NewTicker Advanceで時刻が間隔分進むごとに発火するティッカーを作成する
+func (fc *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("FakeClock: ティッカーの間隔は正の値にしてください")
+	}
+	return fakeTicker{fc.add(&fakeWaiter{period: d, ch: make(chan time.Time, 1)}, d)}
+}
+
+// NewTimer Advanceで時刻がd進んだときに発火するタイマーを作成する
+func (fc *FakeClock) NewTimer(d time.Duration) Timer {
+	return fc.add(&fakeWaiter{ch: make(chan time.Time, 1)}, d)
+}
+
+// AfterFunc Advanceで時刻がd進んだときに関数を呼び出すタイマーを作成する
+func (fc *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	return fc.add(&fakeWaiter{fn: f}, d)
+}
+
+// add 待機中の一覧に追加する
+func (fc *FakeClock) add(w *fakeWaiter, d time.Duration) *fakeWaiter {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	w.clock = fc
+	w.when = fc.now.Add(d)
+	fc.waiters = append(fc.waiters, w)
+	return w
+}
+
+// Waiters 発火を待っているティッカー・タイマーの数を返す
+// ゴルーチンがタイマーを設定し終えたかを確認するのに使う
+func (fc *FakeClock) Waiters() int {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	return len(fc.waiters)
+}
+
+// Advance 時刻をd進め、その間に発火するティッカー・タイマーを時刻の順に発火させる
+func (fc *FakeClock) Advance(d time.Duration) {
+	fc.mu.Lock()
+	end := fc.now.Add(d)
+	for {
+		// 終了時刻までに発火する最も早いものを探す
+		sort.SliceStable(fc.waiters, func(i, j int) bool { return fc.waiters[i].when.Before(fc.waiters[j].when) })
+		if len(fc.waiters) == 0 || fc.waiters[0].when.After(end) {
+			break
+		}
+		w := fc.waiters[0]
+		fc.now = w.when
+		if w.period > 0 {
+			w.when = w.when.Add(w.period)
+		} else {
+			fc.waiters = fc.waiters[1:]
+		}
+
+		if w.fn != nil {
+			fc.mu.Unlock()
+			w.fn() // 呼び出し先がFakeClockを使えるようにロックを外して呼ぶ
+			fc.mu.Lock()
+			continue
+		}
+		select {
+		case w.ch <- fc.now:
+		default:
+			// 受信が追いついていない場合はtime.Tickerと同様に捨てる
+		}
+	}
+	fc.now = end
+	fc.mu.Unlock()
+}
+
+// remove 待機中の一覧から取り除く（取り除いた場合はtrueを返す）
+func (fc *FakeClock) remove(w *fakeWaiter) bool {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	for i, other := range fc.waiters {
+		if other == w {
+			fc.waiters = append(fc.waiters[:i], fc.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// C 時刻を受け取るチャネルを返す
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.ch
+}
+
+// Stop 発火の待機をやめる
+func (w *fakeWaiter) Stop() bool {
+	return w.clock.remove(w)
+}
+
+// fakeTicker fakeWaiterをTickerとして扱うためのラッパー
+type fakeTicker struct{ *fakeWaiter }
+
+// Stop ティッカーを停止する
+func (t fakeTicker) Stop() {
+	t.fakeWaiter.Stop()
+}
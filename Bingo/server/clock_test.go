@@ -0,0 +1,84 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClockFiresInOrder(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	var fired []string
+	clock.AfterFunc(3*time.Second, func() { fired = append(fired, "3s") })
+	clock.AfterFunc(time.Second, func() { fired = append(fired, "1s") })
+	stopped := clock.AfterFunc(2*time.Second, func() { fired = append(fired, "2s") })
+	if !stopped.Stop() {
+		t.Fatal("発火前のタイマーの停止に失敗しました")
+	}
+
+	clock.Advance(2 * time.Second)
+	if len(fired) != 1 || fired[0] != "1s" {
+		t.Fatalf("2秒後に発火したタイマー = %v, want [1s]", fired)
+	}
+	clock.Advance(time.Second)
+	if len(fired) != 2 || fired[1] != "3s" {
+		t.Fatalf("3秒後に発火したタイマー = %v, want [1s 3s]", fired)
+	}
+	if got := clock.Now(); !got.Equal(testStart.Add(3 * time.Second)) {
+		t.Fatalf("Now() = %v, want %v", got, testStart.Add(3*time.Second))
+	}
+	if clock.Waiters() != 0 {
+		t.Fatalf("発火後も%d個のタイマーが残っています", clock.Waiters())
+	}
+}
+
+func TestFakeClockTimer(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	timer := clock.NewTimer(time.Second)
+
+	clock.Advance(999 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatal("期限の前にタイマーが発火しました")
+	default:
+	}
+
+	clock.Advance(time.Millisecond)
+	select {
+	case at := <-timer.C():
+		if !at.Equal(testStart.Add(time.Second)) {
+			t.Fatalf("発火した時刻 = %v, want %v", at, testStart.Add(time.Second))
+		}
+	default:
+		t.Fatal("期限にタイマーが発火しませんでした")
+	}
+	if timer.Stop() {
+		t.Fatal("発火済みのタイマーの停止がtrueを返しました")
+	}
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for i := 1; i <= 3; i++ {
+		clock.Advance(time.Second)
+		select {
+		case at := <-ticker.C():
+			if want := testStart.Add(time.Duration(i) * time.Second); !at.Equal(want) {
+				t.Fatalf("%d回目の発火の時刻 = %v, want %v", i, at, want)
+			}
+		default:
+			t.Fatalf("%d回目の発火がありませんでした", i)
+		}
+	}
+
+	// 受信が追いつかない場合はtime.Tickerと同様に捨てられる
+	clock.Advance(3 * time.Second)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Fatal("受信されなかった発火が溜まっています")
+	default:
+	}
+}
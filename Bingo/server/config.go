@@ -0,0 +1,679 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Config サーバーの設定
+// 既定値、設定ファイル、環境変数、コマンドラインフラグの順に上書きされる
+type Config struct {
+	Addr            string        // 待ち受けるアドレス
+	StaticDir       string        // 静的ファイルを配信するディレクトリ（"-"の場合は配信しない）
+	DefaultInterval int           // WebSocketから作成されたルームのインターバル（秒）
+	CodeLength      int           // ルームのパスワードの長さ
+	AllowedOrigins  []string      // WebSocketの接続を許可するオリジン（空または"*"の場合はすべて許可）
+	TrustedProxies  []string      // X-Forwarded-Forを信頼するリバースプロキシのアドレス（CIDRまたはIP、空の場合は信頼しない）
+	Storage         string        // 数字の保存先（"file:ディレクトリ" または "memory:"）
+	LogLevel        string        // ログの出力レベル（debug, info, warn, error）
+	LogFormat       string        // ログの出力形式（text または json）
+	ShutdownTimeout time.Duration // 停止時に処理中のリクエストを待つ時間
+	AdminToken      string        // 管理APIの認証用トークン（空の場合は管理APIを無効にする）
+	Limits          Limits        // 参加試行やルームの制限
+}
+
+// Limits 参加試行やルームの制限
+type Limits struct {
+	JoinMaxFailures int           // ロックアウトまでの連続失敗回数
+	JoinLockout     time.Duration // ロックアウトの継続時間
+	RoomIdleTimeout time.Duration // 誰も接続していないルームを閉じるまでの時間
+
+	MaxRooms          int // サーバー全体のルーム数の上限
+	MaxRoomsPerIP     int // 一つのIPが同時に持てるルーム数の上限
+	MaxClientsPerRoom int // 一つのルームに同時に接続できるクライアント数の上限
+	MaxPlayersPerRoom int // 一つのルームに登録できるプレイヤー数の上限
+	MaxCardsPerPlayer int // 一人のプレイヤーに一つのラウンドで配るカード数の上限
+}
+
+// 設定の範囲
+const (
+	MinCodeLength  = 4    // パスワードの最短の長さ
+	MaxCodeLength  = 32   // パスワードの最長の長さ
+	MaxIntervalSec = 3600 // インターバルの上限（秒）
+)
+
+// ConfigFileEnv 設定ファイルのパスを指定する環境変数
+const ConfigFileEnv = "BINGO_CONFIG"
+
+// DefaultConfig 既定の設定を返す
+func DefaultConfig() Config {
+	return Config{
+		Addr:            ":8080",
+		StaticDir:       "./frontend",
+		DefaultInterval: 60,
+		CodeLength:      PasswordLength,
+		Storage:         "file:.",
+		LogLevel:        "info",
+		LogFormat:       "text",
+		ShutdownTimeout: 10 * time.Second,
+		Limits: Limits{
+			JoinMaxFailures: JoinMaxFailures,
+			JoinLockout:     JoinLockoutDuration,
+			RoomIdleTimeout: RoomIdleTimeout,
+
+			MaxRooms:          MaxRooms,
+			MaxRoomsPerIP:     MaxRoomsPerIP,
+			MaxClientsPerRoom: MaxClientsPerRoom,
+			MaxPlayersPerRoom: MaxPlayersPerRoom,
+			MaxCardsPerPlayer: MaxCardsPerPlayer,
+		},
+	}
+}
+
+// setting 設定項目ごとの設定ファイルのキー・環境変数・フラグの対応
+type setting struct {
+	key   string                          // 設定ファイルのキー（[limits] 内の項目は "limits.キー"）
+	env   string                          // 環境変数の名前
+	usage string                          // フラグの説明
+	kind  tomlKind                        // 設定ファイルで受け付ける値の種類
+	set   func(c *Config, v string) error // 文字列の値を設定に反映する
+}
+
+// flagName 設定ファイルのキーからフラグ名を作る（"limits.join_lockout" → "limits-join-lockout"）
+func (s setting) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.key)
+}
+
+// 設定項目の一覧
+var settings = []setting{
+	{"addr", "BINGO_ADDR", "待ち受けるアドレス", tomlString, func(c *Config, v string) error {
+		c.Addr = v
+		return nil
+	}},
+	{"static_dir", "BINGO_STATIC_DIR", "静的ファイルのディレクトリ（\"-\"で配信しない）", tomlString, func(c *Config, v string) error {
+		c.StaticDir = v
+		return nil
+	}},
+	{"default_interval", "BINGO_DEFAULT_INTERVAL", "WebSocketから作成されたルームのインターバル（秒）", tomlInteger, func(c *Config, v string) error {
+		return parseInt(v, &c.DefaultInterval)
+	}},
+	{"code_length", "BINGO_CODE_LENGTH", "ルームのパスワードの長さ", tomlInteger, func(c *Config, v string) error {
+		return parseInt(v, &c.CodeLength)
+	}},
+	{"allowed_origins", "BINGO_ALLOWED_ORIGINS", "WebSocketの接続を許可するオリジン（カンマ区切り）", tomlArray, func(c *Config, v string) error {
+		c.AllowedOrigins = splitList(v)
+		return nil
+	}},
+	{"trusted_proxies", "BINGO_TRUSTED_PROXIES", "X-Forwarded-Forを信頼するリバースプロキシのアドレス（CIDRまたはIP、カンマ区切り）", tomlArray, func(c *Config, v string) error {
+		c.TrustedProxies = splitList(v)
+		return nil
+	}},
+	{"storage", "BINGO_STORAGE", "数字の保存先（file:ディレクトリ または memory:）", tomlString, func(c *Config, v string) error {
+		c.Storage = v
+		return nil
+	}},
+	{"log_format", "BINGO_LOG_FORMAT", "ログの出力形式（text または json）", tomlString, func(c *Config, v string) error {
+		c.LogFormat = strings.ToLower(v)
+		return nil
+	}},
+	{"log_level", "BINGO_LOG_LEVEL", "ログの出力レベル（debug, info, warn, error）", tomlString, func(c *Config, v string) error {
+		c.LogLevel = strings.ToLower(v)
+		return nil
+	}},
+	{"admin_token", "BINGO_ADMIN_TOKEN", "管理APIの認証用トークン（空の場合は無効）", tomlString, func(c *Config, v string) error {
+		c.AdminToken = v
+		return nil
+	}},
+	{"shutdown_timeout", "BINGO_SHUTDOWN_TIMEOUT", "停止時に処理中のリクエストを待つ時間（例: 10s）", tomlString, func(c *Config, v string) error {
+		return parseDuration(v, &c.ShutdownTimeout)
+	}},
+	{"limits.join_max_failures", "BINGO_JOIN_MAX_FAILURES", "ロックアウトまでの参加の連続失敗回数", tomlInteger, func(c *Config, v string) error {
+		return parseInt(v, &c.Limits.JoinMaxFailures)
+	}},
+	{"limits.join_lockout", "BINGO_JOIN_LOCKOUT", "ロックアウトの継続時間（例: 15m）", tomlString, func(c *Config, v string) error {
+		return parseDuration(v, &c.Limits.JoinLockout)
+	}},
+	{"limits.room_idle_timeout", "BINGO_ROOM_IDLE_TIMEOUT", "誰も接続していないルームを閉じるまでの時間（例: 30m）", tomlString, func(c *Config, v string) error {
+		return parseDuration(v, &c.Limits.RoomIdleTimeout)
+	}},
+	{"limits.max_rooms", "BINGO_MAX_ROOMS", "サーバー全体のルーム数の上限", tomlInteger, func(c *Config, v string) error {
+		return parseInt(v, &c.Limits.MaxRooms)
+	}},
+	{"limits.max_rooms_per_ip", "BINGO_MAX_ROOMS_PER_IP", "一つのIPが同時に持てるルーム数の上限", tomlInteger, func(c *Config, v string) error {
+		return parseInt(v, &c.Limits.MaxRoomsPerIP)
+	}},
+	{"limits.max_clients_per_room", "BINGO_MAX_CLIENTS_PER_ROOM", "一つのルームに同時に接続できるクライアント数の上限", tomlInteger, func(c *Config, v string) error {
+		return parseInt(v, &c.Limits.MaxClientsPerRoom)
+	}},
+	{"limits.max_players_per_room", "BINGO_MAX_PLAYERS_PER_ROOM", "一つのルームに登録できるプレイヤー数の上限（切断中のプレイヤーも含む）", tomlInteger, func(c *Config, v string) error {
+		return parseInt(v, &c.Limits.MaxPlayersPerRoom)
+	}},
+	{"limits.max_cards_per_player", "BINGO_MAX_CARDS_PER_PLAYER", "一人のプレイヤーに一つのラウンドで配るカード数の上限", tomlInteger, func(c *Config, v string) error {
+		return parseInt(v, &c.Limits.MaxCardsPerPlayer)
+	}},
+}
+
+// LoadConfig コマンドライン引数・環境変数・設定ファイルから設定を読み込み、確認する
+// 設定ファイルは -config フラグまたは環境変数 BINGO_CONFIG で指定する（TOML形式）
+func LoadConfig(args []string, getenv func(string) string) (Config, error) {
+	cfg := DefaultConfig()
+
+	// フラグは最後に反映するため、まず値だけを集める
+	fs := flag.NewFlagSet("bingo", flag.ContinueOnError)
+	configFile := fs.String("config", getenv(ConfigFileEnv), "設定ファイルのパス（TOML形式）")
+	flagValues := make(map[string]string)
+	for _, s := range settings {
+		key := s.key
+		fs.Func(s.flagName(), s.usage+"（環境変数 "+s.env+"）", func(v string) error {
+			flagValues[key] = v
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	if fs.NArg() > 0 {
+		return cfg, fmt.Errorf("不明な引数です: %s", strings.Join(fs.Args(), " "))
+	}
+
+	// 設定ファイル
+	if *configFile != "" {
+		values, err := readConfigFile(*configFile)
+		if err != nil {
+			return cfg, err
+		}
+		if err := applySettings(&cfg, values, "設定ファイル "+*configFile); err != nil {
+			return cfg, err
+		}
+	}
+
+	// 環境変数
+	envValues := make(map[string]string)
+	for _, s := range settings {
+		if v := getenv(s.env); v != "" {
+			envValues[s.key] = v
+		}
+	}
+	if err := applySettings(&cfg, envValues, "環境変数"); err != nil {
+		return cfg, err
+	}
+
+	// コマンドラインフラグ
+	if err := applySettings(&cfg, flagValues, "フラグ"); err != nil {
+		return cfg, err
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// applySettings キーごとの値を設定に反映する
+func applySettings(cfg *Config, values map[string]string, source string) error {
+	for _, s := range settings {
+		v, ok := values[s.key]
+		if !ok {
+			continue
+		}
+		if err := s.set(cfg, v); err != nil {
+			return fmt.Errorf("%s の %s が無効です: %v", source, s.key, err)
+		}
+		delete(values, s.key)
+	}
+	for key := range values {
+		return fmt.Errorf("%s に不明な項目があります: %s", source, key)
+	}
+	return nil
+}
+
+// Validate 設定の値が有効かを確認する
+func (c Config) Validate() error {
+	if c.Addr == "" {
+		return errors.New("待ち受けるアドレスを指定してください")
+	}
+	if c.StaticDir != "-" {
+		info, err := os.Stat(c.StaticDir)
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("静的ファイルのディレクトリが見つかりません: %s", c.StaticDir)
+		}
+	}
+	if c.DefaultInterval < 1 || c.DefaultInterval > MaxIntervalSec {
+		return fmt.Errorf("インターバルは1から%dの範囲で指定してください", MaxIntervalSec)
+	}
+	if c.CodeLength < MinCodeLength || c.CodeLength > MaxCodeLength {
+		return fmt.Errorf("パスワードの長さは%dから%dの範囲で指定してください", MinCodeLength, MaxCodeLength)
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("オリジンが無効です（例: https://example.com）: %s", origin)
+		}
+	}
+	if _, err := parseTrustedProxies(c.TrustedProxies); err != nil {
+		return err
+	}
+	if _, err := OpenStorage(c.Storage); err != nil {
+		return err
+	}
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("ログの出力レベルが無効です: %s", c.LogLevel)
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		return fmt.Errorf("ログの出力形式が無効です（text または json）: %s", c.LogFormat)
+	}
+	if c.AdminToken != "" && len(c.AdminToken) < MinAdminTokenLength {
+		return fmt.Errorf("管理用トークンは%d文字以上で指定してください", MinAdminTokenLength)
+	}
+	if c.ShutdownTimeout <= 0 {
+		return errors.New("停止の待ち時間は正の時間で指定してください")
+	}
+	if c.Limits.JoinMaxFailures < 1 {
+		return errors.New("参加試行の制限回数は1以上で指定してください")
+	}
+	if c.Limits.JoinLockout <= 0 || c.Limits.RoomIdleTimeout <= 0 {
+		return errors.New("ロックアウトとルームの期限は正の時間で指定してください")
+	}
+	if c.Limits.MaxRooms < 1 || c.Limits.MaxRoomsPerIP < 1 || c.Limits.MaxClientsPerRoom < 1 || c.Limits.MaxPlayersPerRoom < 1 || c.Limits.MaxCardsPerPlayer < 1 {
+		return errors.New("ルーム・接続・参加者・カードの上限は1以上で指定してください")
+	}
+	return nil
+}
+
+// readConfigFile 設定ファイルを読み込み、キーごとの値を返す
+// 値の種類が設定項目と合わない場合（整数の項目に文字列を書いた場合など）はエラーにする
+func readConfigFile(path string) (map[string]string, error) {
+	if ext := filepath.Ext(path); ext != ".toml" {
+		return nil, fmt.Errorf("対応していない設定ファイルの形式です（.tomlのみ）: %s", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("設定ファイルを開けませんでした: %v", err)
+	}
+	defer file.Close()
+
+	values, err := parseTOML(file)
+	if err != nil {
+		return nil, fmt.Errorf("設定ファイル %s の読み込みに失敗しました: %v", path, err)
+	}
+	texts := make(map[string]string, len(values))
+	for key, value := range values {
+		for _, s := range settings {
+			if s.key == key && !s.accepts(value.kind) {
+				return nil, fmt.Errorf("設定ファイル %s の %s は%sで指定してください", path, key, s.kind)
+			}
+		}
+		texts[key] = value.text // 不明な項目はapplySettingsでエラーにする
+	}
+	return texts, nil
+}
+
+// tomlKind 設定ファイルの値の種類
+type tomlKind int
+
+// 値の種類
+const (
+	tomlString  tomlKind = iota // 文字列（時間も "15m" のように文字列で書く）
+	tomlInteger                 // 整数
+	tomlFloat                   // 小数
+	tomlBool                    // 真偽値
+	tomlArray                   // 文字列の配列
+)
+
+// String 値の種類の名前を返す（エラーメッセージ用）
+func (k tomlKind) String() string {
+	switch k {
+	case tomlInteger:
+		return "整数"
+	case tomlFloat:
+		return "小数"
+	case tomlBool:
+		return "真偽値"
+	case tomlArray:
+		return "文字列の配列"
+	default:
+		return "文字列"
+	}
+}
+
+// tomlValue 設定ファイルの値
+type tomlValue struct {
+	kind tomlKind // 値の種類
+	text string   // 設定に反映する文字列（配列はカンマ区切り）
+}
+
+// accepts 設定項目が値の種類を受け付けるかを返す
+// 配列の項目は環境変数と同じカンマ区切りの文字列も受け付ける
+func (s setting) accepts(kind tomlKind) bool {
+	return kind == s.kind || (s.kind == tomlArray && kind == tomlString)
+}
+
+// parseTOML 設定ファイルをTOMLの一部として読み込む
+// テーブル内のキーは "テーブル名.キー" として返す。対応しているのは次の書き方だけで、それ以外はエラーにする
+//   - 空行とコメント（#）
+//   - テーブル（[limits] のような一段のもの）
+//   - キー = 値（キーは英数字・_・- のみ）
+//   - 値は文字列（"..." または '...'）、10進数の整数・小数、真偽値、および文字列を一行に並べた配列
+//   - "..." の中ではTOMLのエスケープ（\" \\ \b \t \n \f \r \uXXXX \UXXXXXXXX）が使える
+//
+// テーブルの配列（[[...]]）、インラインテーブル（{...}）、複数行の文字列や配列、
+// ドット区切りや引用符付きのキー、入れ子の配列、日時、10進数以外の整数には対応していない
+func parseTOML(r io.Reader) (map[string]tomlValue, error) {
+	values := make(map[string]tomlValue)
+	section := ""
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line, err := stripComment(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%d行目: %v", lineNo, err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[[") {
+			return nil, unsupportedTOML(lineNo, "テーブルの配列（[[...]]）")
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("%d行目: テーブル名が閉じられていません", lineNo)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if !tomlBareKey.MatchString(section) {
+				return nil, unsupportedTOML(lineNo, "入れ子や引用符付きのテーブル名")
+			}
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%d行目: \"キー = 値\" の形式ではありません", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		if !tomlBareKey.MatchString(key) {
+			return nil, unsupportedTOML(lineNo, "ドット区切りや引用符付きのキー")
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		value, err := parseTOMLValue(strings.TrimSpace(raw), true)
+		if err != nil {
+			return nil, fmt.Errorf("%d行目: %v", lineNo, err)
+		}
+		if _, exists := values[key]; exists {
+			return nil, fmt.Errorf("%d行目: %s が重複しています", lineNo, key)
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// TOMLの書き方の判定に使う正規表現
+var (
+	tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)                                                          // 引用符なしのキー
+	tomlInt     = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)                                                // 10進数の整数（先頭の0は不可）
+	tomlNumber  = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$`) // 小数
+	tomlDate    = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}|^[0-9]{2}:[0-9]{2}`)                            // 日時
+)
+
+// 対応していない書き方のエラーを作成する関数
+func unsupportedTOML(lineNo int, feature string) error {
+	return fmt.Errorf("%d行目: %sには対応していません（文字列・数値・真偽値と一行の配列だけが使えます）", lineNo, feature)
+}
+
+// parseTOMLValue 値を読み取る（配列はカンマ区切りの文字列にする）
+// allowArrayがfalseの場合は配列を受け付けない（入れ子の配列の検出に使う）
+func parseTOMLValue(raw string, allowArray bool) (tomlValue, error) {
+	switch {
+	case strings.HasPrefix(raw, "["):
+		if !allowArray {
+			return tomlValue{}, errors.New("入れ子の配列には対応していません")
+		}
+		if !strings.HasSuffix(raw, "]") {
+			return tomlValue{}, errors.New("配列が閉じられていません（複数行の配列には対応していません）")
+		}
+		items, err := splitTOMLArray(raw[1 : len(raw)-1])
+		if err != nil {
+			return tomlValue{}, err
+		}
+		texts := make([]string, 0, len(items))
+		for _, item := range items {
+			v, err := parseTOMLValue(item, false)
+			if err != nil {
+				return tomlValue{}, err
+			}
+			if v.kind != tomlString {
+				return tomlValue{}, errors.New("配列には文字列だけが使えます")
+			}
+			if strings.Contains(v.text, ",") {
+				return tomlValue{}, errors.New("配列の文字列にカンマは使えません")
+			}
+			texts = append(texts, v.text)
+		}
+		return tomlValue{kind: tomlArray, text: strings.Join(texts, ",")}, nil
+	case strings.HasPrefix(raw, `"""`), strings.HasPrefix(raw, "'''"):
+		return tomlValue{}, errors.New("複数行の文字列には対応していません")
+	case strings.HasPrefix(raw, `"`), strings.HasPrefix(raw, "'"):
+		end, err := tomlStringEnd(raw, 0)
+		if err != nil {
+			return tomlValue{}, err
+		}
+		if end != len(raw)-1 {
+			return tomlValue{}, fmt.Errorf("文字列の後に余分な文字があります: %s", raw[end+1:])
+		}
+		if raw[0] == '\'' {
+			return tomlValue{kind: tomlString, text: raw[1:end]}, nil // リテラル文字列はエスケープを解釈しない
+		}
+		text, err := unescapeTOML(raw[1:end])
+		if err != nil {
+			return tomlValue{}, err
+		}
+		return tomlValue{kind: tomlString, text: text}, nil
+	case strings.HasPrefix(raw, "{"):
+		return tomlValue{}, errors.New("インラインテーブル（{...}）には対応していません")
+	case raw == "":
+		return tomlValue{}, errors.New("値がありません")
+	case raw == "true" || raw == "false":
+		return tomlValue{kind: tomlBool, text: raw}, nil
+	case tomlInt.MatchString(raw):
+		return tomlValue{kind: tomlInteger, text: strings.ReplaceAll(raw, "_", "")}, nil // 1_000 のような区切りを取り除く
+	case tomlNumber.MatchString(raw):
+		return tomlValue{kind: tomlFloat, text: strings.ReplaceAll(raw, "_", "")}, nil
+	case tomlDate.MatchString(raw):
+		return tomlValue{}, errors.New("日時には対応していません")
+	default:
+		return tomlValue{}, fmt.Errorf("値の形式に対応していません（文字列は引用符で囲んでください）: %s", raw)
+	}
+}
+
+// tomlStringEnd s[start]の引用符で始まる文字列を閉じる引用符の位置を返す
+// "..." の中ではバックスラッシュの次の文字を読み飛ばす
+func tomlStringEnd(s string, start int) (int, error) {
+	quote := s[start]
+	for i := start + 1; i < len(s); i++ {
+		switch {
+		case s[i] == quote:
+			return i, nil
+		case s[i] == '\\' && quote == '"':
+			i++
+		}
+	}
+	return 0, errors.New("文字列が閉じられていません")
+}
+
+// unescapeTOML "..." の中身のエスケープをTOMLの規則で解釈する
+func unescapeTOML(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 && c != '\t' || c == 0x7f {
+			return "", errors.New("文字列に制御文字は使えません")
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++ // tomlStringEndで閉じた文字列なので、バックスラッシュの次の文字は必ずある
+		switch s[i] {
+		case 'b':
+			b.WriteByte('\b')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(s[i])
+		case 'u', 'U':
+			digits := 4
+			if s[i] == 'U' {
+				digits = 8
+			}
+			if i+digits >= len(s) {
+				return "", fmt.Errorf("\\%cの後には%d桁の16進数が必要です", s[i], digits)
+			}
+			code, err := strconv.ParseUint(s[i+1:i+1+digits], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", fmt.Errorf("Unicodeのエスケープが正しくありません: \\%s", s[i:i+1+digits])
+			}
+			b.WriteRune(rune(code))
+			i += digits
+		default:
+			return "", fmt.Errorf("対応していないエスケープです: \\%c", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// splitTOMLArray 配列の中身を文字列の外にあるカンマで分割する
+func splitTOMLArray(v string) ([]string, error) {
+	var items []string
+	start := 0
+	for i := 0; i < len(v); i++ {
+		switch v[i] {
+		case '"', '\'':
+			end, err := tomlStringEnd(v, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case ',':
+			items = append(items, v[start:i])
+			start = i + 1
+		}
+	}
+	items = append(items, v[start:])
+
+	trimmed := items[:0]
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			trimmed = append(trimmed, item) // 末尾のカンマを許す
+		}
+	}
+	return trimmed, nil
+}
+
+// stripComment 文字列の外にある # 以降を取り除く
+func stripComment(line string) (string, error) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"', '\'':
+			end, err := tomlStringEnd(line, i)
+			if err != nil {
+				return "", err
+			}
+			i = end
+		case '#':
+			return line[:i], nil
+		}
+	}
+	return line, nil
+}
+
+// カンマ区切りの文字列を空白を除いて分割する関数
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// 整数の値を読み取る関数
+func parseInt(v string, dst *int) error {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("整数ではありません: %s", v)
+	}
+	*dst = n
+	return nil
+}
+
+// 時間の値を読み取る関数（"15m" や "30s" の形式）
+func parseDuration(v string, dst *time.Duration) error {
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("時間の形式ではありません: %s", v)
+	}
+	*dst = d
+	return nil
+}
+
+// parseTrustedProxies 信頼するリバースプロキシのアドレスをネットワークの一覧に変換する
+// CIDRのほかに単独のIPアドレスも受け付ける
+func parseTrustedProxies(list []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, entry := range list {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("信頼するプロキシのアドレスが無効です（例: 10.0.0.0/8）: %s", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("信頼するプロキシのアドレスが無効です（例: 10.0.0.0/8）: %s", entry)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// originAllowed オリジンが接続を許可されているかを返す
+// Originヘッダーのないリクエスト（ブラウザ以外）は許可する
+func (c Config) originAllowed(origin string) bool {
+	if origin == "" || len(c.AllowedOrigins) == 0 {
+		return true
+	}
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,293 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile 一時ディレクトリに設定ファイルを作成し、パスを返す
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bingo.toml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// envFrom マップから値を返すgetenvを作成する
+func envFrom(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	// static_dirとstorageはValidateがファイルシステムを見ないように上書きする
+	file := writeConfigFile(t, `
+static_dir = "-"
+storage = "memory:"
+addr = ":9001"
+default_interval = 30
+code_length = 8
+
+[limits]
+max_rooms = 10
+`)
+
+	tests := []struct {
+		name  string
+		args  []string
+		env   map[string]string
+		check func(t *testing.T, cfg Config)
+	}{
+		{
+			name: "既定値",
+			args: []string{"-static-dir", "-", "-storage", "memory:"},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Addr != ":8080" || cfg.DefaultInterval != 60 || cfg.Limits.MaxRooms != MaxRooms {
+					t.Fatalf("既定値になっていません: %+v", cfg)
+				}
+			},
+		},
+		{
+			name: "設定ファイルが既定値を上書きする",
+			args: []string{"-config", file},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Addr != ":9001" || cfg.DefaultInterval != 30 || cfg.CodeLength != 8 || cfg.Limits.MaxRooms != 10 {
+					t.Fatalf("設定ファイルの値になっていません: %+v", cfg)
+				}
+				if cfg.LogLevel != "info" {
+					t.Fatalf("設定ファイルにない項目が既定値になっていません: %s", cfg.LogLevel)
+				}
+			},
+		},
+		{
+			name: "環境変数で設定ファイルを指定する",
+			env:  map[string]string{ConfigFileEnv: file},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Addr != ":9001" {
+					t.Fatalf("Addr = %q, want %q", cfg.Addr, ":9001")
+				}
+			},
+		},
+		{
+			name: "環境変数が設定ファイルを上書きする",
+			args: []string{"-config", file},
+			env:  map[string]string{"BINGO_ADDR": ":9002", "BINGO_MAX_ROOMS": "20"},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Addr != ":9002" || cfg.Limits.MaxRooms != 20 || cfg.DefaultInterval != 30 {
+					t.Fatalf("環境変数の値になっていません: %+v", cfg)
+				}
+			},
+		},
+		{
+			name: "フラグが環境変数を上書きする",
+			args: []string{"-config", file, "-addr", ":9003", "-limits-max-rooms", "30"},
+			env:  map[string]string{"BINGO_ADDR": ":9002", "BINGO_MAX_ROOMS": "20", "BINGO_CODE_LENGTH": "12"},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Addr != ":9003" || cfg.Limits.MaxRooms != 30 || cfg.CodeLength != 12 || cfg.DefaultInterval != 30 {
+					t.Fatalf("フラグの値になっていません: %+v", cfg)
+				}
+			},
+		},
+		{
+			name: "配列はカンマ区切りの環境変数でも指定できる",
+			args: []string{"-static-dir", "-", "-storage", "memory:"},
+			env:  map[string]string{"BINGO_ALLOWED_ORIGINS": "https://a.example, https://b.example", "BINGO_JOIN_LOCKOUT": "5m"},
+			check: func(t *testing.T, cfg Config) {
+				if want := []string{"https://a.example", "https://b.example"}; !reflect.DeepEqual(cfg.AllowedOrigins, want) {
+					t.Fatalf("AllowedOrigins = %v, want %v", cfg.AllowedOrigins, want)
+				}
+				if cfg.Limits.JoinLockout != 5*time.Minute {
+					t.Fatalf("JoinLockout = %v, want 5m", cfg.Limits.JoinLockout)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig(tt.args, envFrom(tt.env))
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{"不明なフラグ", []string{"-unknown"}, nil, "flag provided but not defined"},
+		{"余分な引数", []string{"extra"}, nil, "不明な引数です"},
+		{"整数でない環境変数", nil, map[string]string{"BINGO_CODE_LENGTH": "abc"}, "環境変数 の code_length が無効です"},
+		{"時間でないフラグ", []string{"-shutdown-timeout", "10"}, nil, "フラグ の shutdown_timeout が無効です"},
+		{"TOML以外の設定ファイル", []string{"-config", "bingo.json"}, nil, "対応していない設定ファイルの形式です"},
+		{"存在しない設定ファイル", []string{"-config", filepath.Join(t.TempDir(), "none.toml")}, nil, "設定ファイルを開けませんでした"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(tt.args, envFrom(tt.env))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("LoadConfig err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"不明な項目", `unknown = "x"`, "不明な項目があります: unknown"},
+		{"不明なテーブルの項目", "[limits]\nunknown = 1", "不明な項目があります: limits.unknown"},
+		{"引用符で囲んだ整数", `default_interval = "60"`, "default_interval は整数で指定してください"},
+		{"小数の整数項目", `default_interval = 1.5`, "default_interval は整数で指定してください"},
+		{"整数の文字列項目", `addr = 8080`, "addr は文字列で指定してください"},
+		{"真偽値の文字列項目", `log_level = true`, "log_level は文字列で指定してください"},
+		{"整数の時間項目", "[limits]\njoin_lockout = 900", "limits.join_lockout は文字列で指定してください"},
+		{"整数の配列項目", `allowed_origins = 1`, "allowed_origins は文字列の配列で指定してください"},
+		{"時間の形式でない値", `shutdown_timeout = "10"`, "shutdown_timeout が無効です"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, tt.content)
+			_, err := LoadConfig([]string{"-config", path, "-static-dir", "-", "-storage", "memory:"}, envFrom(nil))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("LoadConfig err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{"アドレスが空", func(c *Config) { c.Addr = "" }, "待ち受けるアドレス"},
+		{"静的ファイルのディレクトリがない", func(c *Config) { c.StaticDir = filepath.Join(t.TempDir(), "none") }, "静的ファイルのディレクトリが見つかりません"},
+		{"インターバルが0", func(c *Config) { c.DefaultInterval = 0 }, "インターバルは1から"},
+		{"インターバルが上限を超える", func(c *Config) { c.DefaultInterval = MaxIntervalSec + 1 }, "インターバルは1から"},
+		{"パスワードが短い", func(c *Config) { c.CodeLength = MinCodeLength - 1 }, "パスワードの長さ"},
+		{"パスワードが長い", func(c *Config) { c.CodeLength = MaxCodeLength + 1 }, "パスワードの長さ"},
+		{"スキームのないオリジン", func(c *Config) { c.AllowedOrigins = []string{"example.com"} }, "オリジンが無効です"},
+		{"パス付きのオリジン", func(c *Config) { c.AllowedOrigins = []string{"https://example.com/app"} }, "オリジンが無効です"},
+		{"無効なプロキシ", func(c *Config) { c.TrustedProxies = []string{"proxy.example"} }, "proxy.example"},
+		{"無効な保存先", func(c *Config) { c.Storage = "s3:bucket" }, "s3:bucket"},
+		{"無効なログレベル", func(c *Config) { c.LogLevel = "trace" }, "ログの出力レベルが無効です"},
+		{"無効なログ形式", func(c *Config) { c.LogFormat = "xml" }, "ログの出力形式が無効です"},
+		{"短い管理用トークン", func(c *Config) { c.AdminToken = strings.Repeat("x", MinAdminTokenLength-1) }, "管理用トークン"},
+		{"停止の待ち時間が0", func(c *Config) { c.ShutdownTimeout = 0 }, "停止の待ち時間"},
+		{"参加試行の制限回数が0", func(c *Config) { c.Limits.JoinMaxFailures = 0 }, "参加試行の制限回数"},
+		{"ロックアウトが0", func(c *Config) { c.Limits.JoinLockout = 0 }, "ロックアウトとルームの期限"},
+		{"ルームの期限が負", func(c *Config) { c.Limits.RoomIdleTimeout = -time.Second }, "ロックアウトとルームの期限"},
+		{"ルーム数の上限が0", func(c *Config) { c.Limits.MaxRooms = 0 }, "上限は1以上"},
+		{"IPごとのルーム数の上限が0", func(c *Config) { c.Limits.MaxRoomsPerIP = 0 }, "上限は1以上"},
+		{"接続数の上限が0", func(c *Config) { c.Limits.MaxClientsPerRoom = 0 }, "上限は1以上"},
+		{"参加者数の上限が0", func(c *Config) { c.Limits.MaxPlayersPerRoom = 0 }, "上限は1以上"},
+		{"カード数の上限が0", func(c *Config) { c.Limits.MaxCardsPerPlayer = 0 }, "上限は1以上"},
+	}
+
+	valid := DefaultConfig()
+	valid.StaticDir = "-"
+	valid.Storage = "memory:"
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("有効な設定でエラーになりました: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]tomlValue
+	}{
+		{"コメントと空行", "# コメント\n\naddr = \":80\" # 行末のコメント\n", map[string]tomlValue{"addr": {tomlString, ":80"}}},
+		{"基本文字列の中の#", `addr = "a#b"`, map[string]tomlValue{"addr": {tomlString, "a#b"}}},
+		{"エスケープした引用符の後の#", `addr = "a\"#b"`, map[string]tomlValue{"addr": {tomlString, `a"#b`}}},
+		{"エスケープした引用符の後のコメント", `addr = "a\"" # "b`, map[string]tomlValue{"addr": {tomlString, `a"`}}},
+		{"末尾のバックスラッシュ", `addr = "a\\" # コメント`, map[string]tomlValue{"addr": {tomlString, `a\`}}},
+		{"リテラル文字列", `addr = 'C:\path\#1'`, map[string]tomlValue{"addr": {tomlString, `C:\path\#1`}}},
+		{"エスケープ", `addr = "\t\n\\\"\b\f\r"`, map[string]tomlValue{"addr": {tomlString, "\t\n\\\"\b\f\r"}}},
+		{"Unicodeのエスケープ", `addr = "\u00e9\U0001F600"`, map[string]tomlValue{"addr": {tomlString, "é😀"}}},
+		{"整数", "a = 42\nb = -7\nc = 1_000\nd = +0", map[string]tomlValue{"a": {tomlInteger, "42"}, "b": {tomlInteger, "-7"}, "c": {tomlInteger, "1000"}, "d": {tomlInteger, "+0"}}},
+		{"小数と真偽値", "a = 1.5\nb = 1e3\nc = true", map[string]tomlValue{"a": {tomlFloat, "1.5"}, "b": {tomlFloat, "1e3"}, "c": {tomlBool, "true"}}},
+		{"配列", `a = ["x", 'y#', "z\"", ]`, map[string]tomlValue{"a": {tomlArray, `x,y#,z"`}}},
+		{"空の配列", `a = []`, map[string]tomlValue{"a": {tomlArray, ""}}},
+		{"テーブル", "[limits]\nmax_rooms = 5", map[string]tomlValue{"limits.max_rooms": {tomlInteger, "5"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("parseTOML: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseTOML = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"閉じていない文字列", `addr = "abc`, "文字列が閉じられていません"},
+		{"エスケープで終わる文字列", `addr = "abc\"`, "文字列が閉じられていません"},
+		{"閉じていないリテラル文字列", `addr = 'abc`, "文字列が閉じられていません"},
+		{"文字列の後の余分な文字", `addr = "a" "b"`, "余分な文字があります"},
+		{"Goだけのエスケープ", `addr = "\x41"`, "対応していないエスケープです"},
+		{"短いUnicodeのエスケープ", `addr = "\u12"`, "4桁の16進数"},
+		{"無効なUnicodeのエスケープ", `addr = "\uD800"`, "Unicodeのエスケープが正しくありません"},
+		{"先頭が0の整数", `a = 007`, "値の形式に対応していません"},
+		{"16進数の整数", `a = 0x10`, "値の形式に対応していません"},
+		{"引用符のない文字列", `addr = localhost`, "引用符で囲んでください"},
+		{"値がない", `addr =`, "値がありません"},
+		{"キーと値の形式でない", `addr`, "キー = 値"},
+		{"重複したキー", "a = 1\na = 2", "a が重複しています"},
+		{"閉じていないテーブル名", `[limits`, "テーブル名が閉じられていません"},
+		{"入れ子のテーブル名", `[limits.sub]`, "入れ子や引用符付きのテーブル名"},
+		{"テーブルの配列", `[[rooms]]`, "テーブルの配列"},
+		{"ドット区切りのキー", `limits.max_rooms = 1`, "ドット区切りや引用符付きのキー"},
+		{"引用符付きのキー", `"addr" = ":80"`, "ドット区切りや引用符付きのキー"},
+		{"インラインテーブル", `limits = { max_rooms = 1 }`, "インラインテーブル"},
+		{"複数行の文字列", `addr = """abc"""`, "複数行の文字列"},
+		{"複数行の配列", `a = [`, "配列が閉じられていません"},
+		{"入れ子の配列", `a = [["x"]]`, "入れ子の配列"},
+		{"文字列以外の配列", `a = [1, 2]`, "配列には文字列だけが使えます"},
+		{"カンマを含む配列の文字列", `a = ["x,y"]`, "カンマは使えません"},
+		{"日時", `a = 2024-01-01T00:00:00Z`, "日時には対応していません"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML(strings.NewReader(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("parseTOML err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
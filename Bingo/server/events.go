@@ -0,0 +1,111 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"bingo/bingo"
+)
+
+// 購読者ごとのイベントバッファのサイズ
+const SubscriberBuffer = 64
+
+// RoomEvent ルームの参加者に配信するイベント
+type RoomEvent struct {
+	Type string      `json:"type"` // イベントの種類（draw など）
+	ID   string      `json:"-"`    // SSEのイベントID（"ラウンド-通し番号"、空の場合は付与しない）
+	Data interface{} `json:"data"` // イベントの内容
+}
+
+// Subscribe ルームのイベントを購読する
+// 現在のラウンドで引かれた数字のうち、lastEventIDより後のものを合わせて返す
+func (room *Room) Subscribe(lastEventID string) (chan RoomEvent, []RoomEvent) {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	// 別のラウンドのIDの場合は現在のラウンドの最初から送る
+	after := 0
+	if round, ordinal, ok := parseEventID(lastEventID); ok && round == room.Round.Number {
+		after = ordinal
+	}
+
+	var backlog []RoomEvent
+	for _, draw := range room.drawsSinceLocked(after) {
+		backlog = append(backlog, newDrawEvent(room.Round.Number, draw))
+	}
+
+	ch := make(chan RoomEvent, SubscriberBuffer)
+	if room.State == RoomClosed {
+		close(ch) // 閉じられたルームには購読させない
+		return ch, backlog
+	}
+	if room.subscribers == nil {
+		room.subscribers = make(map[chan RoomEvent]struct{})
+	}
+	room.subscribers[ch] = struct{}{}
+	return ch, backlog
+}
+
+// Unsubscribe ルームのイベントの購読をやめる
+func (room *Room) Unsubscribe(ch chan RoomEvent) {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	if _, exists := room.subscribers[ch]; exists {
+		delete(room.subscribers, ch)
+		close(ch)
+	}
+	room.LastActivity = room.now() // 期限は最後の購読者が離れた時点から数える
+}
+
+// Publish ルームのすべての購読者にイベントを配信する
+func (room *Room) Publish(ev RoomEvent) {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	room.publishLocked(ev)
+}
+
+// publishLocked ルームのすべての購読者にイベントを配信する（room.Mutexを保持して呼び出すこと）
+// 受信が追いつかない購読者は切断し、再接続時に取りこぼしを補ってもらう
+func (room *Room) publishLocked(ev RoomEvent) {
+	start := time.Now()
+	defer func() { room.manager.metrics.Broadcast.Observe(time.Since(start).Seconds()) }()
+
+	for ch := range room.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			room.logger().Warn("受信が遅いクライアントを切断しました", LogKeyEvent, "slow_client_dropped", "roomEvent", ev.Type)
+			room.manager.metrics.SlowClientsDropped.Inc()
+			delete(room.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// closeSubscribersLocked すべての購読を終了する（room.Mutexを保持して呼び出すこと）
+func (room *Room) closeSubscribersLocked() {
+	for ch := range room.subscribers {
+		close(ch)
+	}
+	room.subscribers = nil
+}
+
+// 数字が引かれたイベントを作成する関数
+func newDrawEvent(round int, draw bingo.Draw) RoomEvent {
+	return RoomEvent{
+		Type: "draw",
+		ID:   fmt.Sprintf("%d-%d", round, draw.Ordinal),
+		Data: draw,
+	}
+}
+
+// "ラウンド-通し番号" の形式のイベントIDを読み取る関数
+func parseEventID(id string) (int, int, bool) {
+	var round, ordinal int
+	if _, err := fmt.Sscanf(id, "%d-%d", &round, &ordinal); err != nil {
+		return 0, 0, false
+	}
+	return round, ordinal, true
+}
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Options Serverの作成時に差し替えられる依存先
+// 省略した項目には既定の実装を使用する
+type Options struct {
+	Config  *Config      // サーバーの設定（省略時は既定の設定）
+	Storage Storage      // 引かれた数字の保存先（省略時はConfig.Storageから作成）
+	Clock   Clock        // 現在時刻を返す時計（省略時はシステムの時計）
+	Source  rand.Source  // ルームIDやプレイヤーIDなど公開するIDの生成に使う乱数源（省略時は現在時刻で初期化）
+	Logger  *slog.Logger // ログの出力先（省略時はConfigのレベルと形式で標準エラー出力に出す）
+
+	// DeckSource 抽選の順番とカードの生成に使う乱数源（省略時は暗号論的乱数で初期化）
+	// 公開するIDから乱数列を推測されないようにSourceとは別にする
+	DeckSource rand.Source
+}
+
+// Server構造体 ルームの管理と抽選を行い、HTTPとWebSocketのエンドポイントを提供する
+// 一つのプロセスで複数のServerを独立して動かせる
+type Server struct {
+	config    Config             // サーバーの設定
+	logger    *slog.Logger       // ログの出力先
+	clock     Clock              // 現在時刻を返す時計（RoomManagerと共有する）
+	metrics   *Metrics           // 計測値（RoomManagerと共有する）
+	rooms     *RoomManager       // ルームを管理するRoomManager
+	scheduler *DrawScheduler     // 数字抽選のスケジューラー
+	limiter   *JoinLimiter       // 参加試行のレート制限
+	proxies   []*net.IPNet       // X-Forwarded-Forを信頼するリバースプロキシのネットワーク
+	upgrader  websocket.Upgrader // WebSocketのアップグレーダー
+	mux       *http.ServeMux     // エンドポイントを登録したマルチプレクサー
+	quit      chan struct{}      // バックグラウンド処理の停止要求用のチャネル
+	startOnce sync.Once          // Startを一度だけ実行するため
+	stopOnce  sync.Once          // バックグラウンド処理の停止を一度だけ実行するため
+	draining  atomic.Bool        // 停止処理中で新しい参加を受け付けないかどうか
+	writers   sync.WaitGroup     // WebSocketへ書き込み中のゴルーチン
+}
+
+// 新しいServerインスタンスを作成
+// バックグラウンド処理はStartを呼ぶまで動かない
+func New(opts Options) (*Server, error) {
+	cfg := DefaultConfig()
+	if opts.Config != nil {
+		cfg = *opts.Config
+	}
+	if opts.Storage == nil {
+		storage, err := OpenStorage(cfg.Storage)
+		if err != nil {
+			return nil, err
+		}
+		opts.Storage = storage
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock{}
+	}
+	if opts.Source == nil {
+		opts.Source = rand.NewSource(time.Now().UnixNano())
+	}
+	if opts.DeckSource == nil {
+		source, err := newSecretSource()
+		if err != nil {
+			return nil, err
+		}
+		opts.DeckSource = source
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = NewLogger(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	}
+	proxies, err := parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := NewRoomManager(cfg, opts.Storage, opts.Clock, newLockedRand(opts.Source), newLockedRand(opts.DeckSource), opts.Logger)
+	s := &Server{
+		config:    cfg,
+		logger:    opts.Logger,
+		clock:     opts.Clock,
+		metrics:   rooms.metrics,
+		rooms:     rooms,
+		scheduler: NewDrawScheduler(rooms),
+		limiter:   NewJoinLimiter(opts.Clock, cfg.Limits, rooms.metrics, opts.Logger),
+		proxies:   proxies,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return cfg.originAllowed(r.Header.Get("Origin")) // 設定されたオリジンからの接続だけを許可する
+			},
+		},
+		mux:  http.NewServeMux(),
+		quit: make(chan struct{}),
+	}
+	s.routes(cfg.StaticDir)
+	return s, nil
+}
+
+// routes エンドポイントを登録する
+func (s *Server) routes(staticDir string) {
+	// 静的ファイルの配信
+	if staticDir != "-" {
+		s.mux.Handle("/", http.FileServer(http.Dir(staticDir)))
+	}
+	// WebSocketエンドポイント
+	s.mux.HandleFunc("/ws", s.handleConnections)
+	// ルーム作成エンドポイント
+	s.mux.HandleFunc("/create-room", s.CreateRoomHandler)
+	// ルームに参加するエンドポイント
+	s.mux.HandleFunc("/join-room", s.JoinRoomHandler)
+	// 新しいゲームを開始するエンドポイント
+	s.mux.HandleFunc("/new-game", s.NewGameHandler)
+	// ビンゴチェックのエンドポイント
+	s.mux.HandleFunc("/check-bingo", s.CheckBingoHandler)
+	// 生成された数字のリストをリセットするエンドポイント
+	s.mux.HandleFunc("/reset-generated-numbers", s.ResetGeneratedNumbersHandler)
+	// ホストがルームを操作するエンドポイント
+	s.mux.HandleFunc("/room-control", s.RoomControlHandler)
+
+	// ルームごとの数字取得エンドポイント
+	s.mux.HandleFunc("/get-room-numbers", s.GetRoomNumbersHandler)
+	// ルームの抽選履歴を取得するエンドポイント
+	s.mux.HandleFunc("/room-history", s.DrawHistoryHandler)
+	// 大画面表示用のエンドポイント（閲覧専用コードで参照）
+	s.mux.HandleFunc("/board", s.BoardHandler)
+	s.mux.HandleFunc("/board-feed", s.BoardFeedHandler)
+	// 勝者の一覧の取得・リセットのエンドポイント（ホスト用）
+	s.mux.HandleFunc("/winners", s.WinnersHandler)
+	s.mux.HandleFunc("/reset-winners", s.ResetWinnersHandler)
+	// ラウンドの切り替え（ホスト用）と履歴のエンドポイント
+	s.mux.HandleFunc("/next-round", s.NextRoundHandler)
+	s.mux.HandleFunc("/rounds", s.RoundsHandler)
+	// 勝ちに近いプレイヤーの一覧のエンドポイント
+	s.mux.HandleFunc("/leaderboard", s.LeaderboardHandler)
+	// 計測値のエンドポイント（Prometheus形式）
+	s.mux.HandleFunc("/metrics", s.MetricsHandler)
+	// 運用者向けの管理API（管理用トークンが必要）
+	s.mux.HandleFunc("/admin/rooms", s.adminOnly(s.AdminRoomsHandler))
+	s.mux.HandleFunc("/admin/room", s.adminOnly(s.AdminRoomHandler))
+	s.mux.HandleFunc("/admin/close-room", s.adminOnly(s.AdminCloseRoomHandler))
+	s.mux.HandleFunc("/admin/kick", s.adminOnly(s.AdminKickHandler))
+	// 死活監視と準備状態のエンドポイント（リバースプロキシ用）
+	s.mux.HandleFunc("/healthz", s.HealthHandler)
+	s.mux.HandleFunc("/readyz", s.ReadyHandler)
+}
+
+// Handler すべてのエンドポイントを処理するhttp.Handlerを返す
+// リクエストごとにリクエストIDを割り当て、処理時間を計測する
+func (s *Server) Handler() http.Handler {
+	return s.withRequestLogger(s.withMetrics(s.mux))
+}
+
+// Rooms ルームを管理するRoomManagerを返す
+func (s *Server) Rooms() *RoomManager {
+	return s.rooms
+}
+
+// Start 数字の抽選とルーム・レート制限の掃除のゴルーチンを起動する
+func (s *Server) Start() {
+	s.startOnce.Do(func() {
+		s.scheduler.Start()            // 数字抽選のスケジューラーを起動
+		go s.limiter.pruneLoop(s.quit) // レート制限の状態を掃除するゴルーチンを起動
+		go s.rooms.expireLoop(s.quit)  // 使われていないルームを閉じるゴルーチンを起動
+	})
+}
+
+// stop 数字の抽選とバックグラウンドのゴルーチンを停止する
+func (s *Server) stop() {
+	s.stopOnce.Do(func() {
+		close(s.quit)
+		if s.scheduler.Running() {
+			s.scheduler.Stop() // 抽選の途中で止まらないよう、ゴルーチンの終了を待つ
+		}
+	})
+}
+
+// Close バックグラウンドのゴルーチンを停止し、すべてのルームを閉じる（データも削除する）
+// データを残して停止する場合はShutdownを使う
+func (s *Server) Close() {
+	s.draining.Store(true)
+	s.stop()
+	for _, room := range s.rooms.ListRooms() {
+		s.rooms.CloseRoom(room.Password, "shutdown")
+	}
+}
+
+// Run 設定に従ってServerを作成し、サーバーを開始する
+// SIGINT・SIGTERMを受け取るとクライアントに通知してから停止する
+func Run(cfg Config) error {
+	s, err := New(Options{Config: &cfg})
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(s.logger) // logパッケージの出力も同じ形式にする
+	s.Start()
+
+	httpServer := &http.Server{Addr: cfg.Addr, Handler: s.Handler()}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.logger.Info("Listening", LogKeyEvent, "listen", "addr", cfg.Addr)
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		s.Close()
+		return err
+	case <-ctx.Done():
+		stop() // もう一度シグナルを受け取った場合はすぐに終了する
+	}
+
+	s.logger.Info("停止の要求を受け取りました", LogKeyEvent, "shutdown", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	shutdownErr := s.Shutdown(shutdownCtx)
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("HTTPサーバーの停止に失敗しました: %v", err)
+	}
+	if shutdownErr != nil {
+		return shutdownErr
+	}
+	s.logger.Info("サーバーを停止しました", LogKeyEvent, "stopped")
+	return nil
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testStart テストで使う時計の開始時刻
+var testStart = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestServer メモリの保存先・FakeClock・固定の乱数源でServerを作成し、httptestで公開する
+// configureで設定を変更できる（nilの場合は既定の設定）
+func newTestServer(t *testing.T, configure func(*Config)) (*Server, *FakeClock, *httptest.Server) {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.Storage = "memory:"
+	cfg.StaticDir = "-"
+	if configure != nil {
+		configure(&cfg)
+	}
+	clock := NewFakeClock(testStart)
+	s, err := New(Options{
+		Config:     &cfg,
+		Storage:    NewMemoryStorage(),
+		Clock:      clock,
+		Source:     rand.NewSource(1),
+		DeckSource: rand.NewSource(2),
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Serverの作成に失敗しました: %v", err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		ts.Close()
+		s.Close()
+	})
+	return s, clock, ts
+}
+
+// postJSON JSONの本文でPOSTし、ステータスコードとデコードした本文を返す
+func postJSON(t *testing.T, url string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("POST %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&decoded) // エラー時の本文はJSONでない場合がある
+	return resp.StatusCode, decoded
+}
+
+// createTestRoom ルームを作成してパスワードとホスト用トークンを返す
+func createTestRoom(t *testing.T, ts *httptest.Server, body map[string]interface{}) (string, string) {
+	t.Helper()
+
+	status, resp := postJSON(t, ts.URL+"/create-room", body)
+	if status != http.StatusOK {
+		t.Fatalf("ルームの作成: ステータス %d, %v", status, resp)
+	}
+	return resp["password"].(string), resp["hostToken"].(string)
+}
+
+func TestServersAreIndependent(t *testing.T) {
+	a, _, tsA := newTestServer(t, func(cfg *Config) { cfg.Limits.MaxRooms = 1 })
+	b, _, tsB := newTestServer(t, nil)
+
+	password, _ := createTestRoom(t, tsA, map[string]interface{}{"interval": 5})
+
+	// 片方で作成したルームにはもう片方から参加できない
+	if status, _ := postJSON(t, tsB.URL+"/join-room", map[string]string{"password": password}); status != http.StatusUnauthorized {
+		t.Fatalf("別のServerのルームへの参加: ステータス %d, want %d", status, http.StatusUnauthorized)
+	}
+	// レート制限もServerごとなので、もう片方での参加は待たされない
+	if status, resp := postJSON(t, tsA.URL+"/join-room", map[string]string{"password": password}); status != http.StatusOK {
+		t.Fatalf("ルームへの参加: ステータス %d, %v", status, resp)
+	}
+
+	if n := len(a.Rooms().ListRooms()); n != 1 {
+		t.Fatalf("Server Aのルーム数 = %d, want 1", n)
+	}
+	if n := len(b.Rooms().ListRooms()); n != 0 {
+		t.Fatalf("Server Bのルーム数 = %d, want 0", n)
+	}
+
+	// 設定もServerごと（AはMaxRooms=1、Bは既定の上限）
+	if status, resp := postJSON(t, tsA.URL+"/create-room", map[string]interface{}{"interval": 5}); status != http.StatusServiceUnavailable || resp["code"] != "room_limit" {
+		t.Fatalf("上限を超えたルームの作成: ステータス %d, %v", status, resp)
+	}
+	createTestRoom(t, tsB, map[string]interface{}{"interval": 5})
+	createTestRoom(t, tsB, map[string]interface{}{"interval": 5})
+	if n := len(b.Rooms().ListRooms()); n != 2 {
+		t.Fatalf("Server Bのルーム数 = %d, want 2", n)
+	}
+
+	// 片方を閉じてももう片方のルームは残る
+	a.Close()
+	if n := len(b.Rooms().ListRooms()); n != 2 {
+		t.Fatalf("Server Aを閉じた後のServer Bのルーム数 = %d, want 2", n)
+	}
+}
+
+func TestServersHaveSeparateClocks(t *testing.T) {
+	a, clockA, tsA := newTestServer(t, nil)
+	b, _, tsB := newTestServer(t, nil)
+	a.Start()
+	b.Start()
+
+	passwordA, _ := createTestRoom(t, tsA, map[string]interface{}{"interval": 2})
+	passwordB, _ := createTestRoom(t, tsB, map[string]interface{}{"interval": 2})
+	roomA := a.Rooms().GetRoomByPassword(passwordA)
+	roomB := b.Rooms().GetRoomByPassword(passwordB)
+	for _, room := range []*Room{roomA, roomB} {
+		if err := room.Transition(RoomRunning); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.scheduler.Wake()
+	b.scheduler.Wake()
+
+	// Aの時計だけを進めると、Aのルームだけで数字が引かれる
+	advanceUntil(t, clockA, 100*time.Millisecond, func() bool { return drawCount(roomA) == 1 })
+	if n := drawCount(roomB); n != 0 {
+		t.Fatalf("時計を進めていないServer Bで%d個の数字が引かれました", n)
+	}
+}
+
+// advanceUntil 条件を満たすまでFakeClockを少しずつ進める
+// バックグラウンドのゴルーチンがタイマーを設定し直す時間を取るため、進めるたびに少し待つ
+func advanceUntil(t *testing.T, clock *FakeClock, step time.Duration, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("条件を満たしませんでした（時計: %s）", clock.Now().Sub(testStart))
+		}
+		clock.Advance(step)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// drawCount ルームの現在のラウンドで引かれた数字の数を返す
+func drawCount(room *Room) int {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	return len(room.Round.Game.Draws())
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ReadinessTimeout 準備状態の確認で保存先の応答を待つ時間
+const ReadinessTimeout = 2 * time.Second
+
+// 保存先が時間内に応答しなかったことを表すエラー
+var errStorageTimeout = errors.New("保存先が応答しません")
+
+// HealthHandler プロセスが動いていることを返すハンドラー関数
+func (s *Server) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// ReadyHandler リクエストを受け付けられる状態かを返すハンドラー関数
+// 保存先が応答し、抽選のスケジューラーが動いていて、停止処理中でなければ準備完了とする
+func (s *Server) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]string{
+		"storage":   "ok",
+		"scheduler": "ok",
+		"shutdown":  "ok",
+	}
+	ready := true
+
+	if err := s.pingStorage(); err != nil {
+		checks["storage"] = err.Error()
+		ready = false
+	}
+	if !s.scheduler.Running() {
+		checks["scheduler"] = "stopped"
+		ready = false
+	}
+	if s.Draining() {
+		checks["shutdown"] = "draining"
+		ready = false
+	}
+
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not ready", http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// pingStorage 保存先が一定時間内に応答するかを確認する
+func (s *Server) pingStorage() error {
+	result := make(chan error, 1)
+	go func() {
+		result <- s.rooms.storage.Ping()
+	}()
+
+	timer := s.clock.NewTimer(ReadinessTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C():
+		return errStorageTimeout
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bingo/bingo"
+)
+
+// 抽選履歴の取得件数に関する定数
+const (
+	HistoryDefaultLimit = 20              // limitを省略した場合の件数
+	HistoryMaxLimit     = bingo.MaxNumber // 一度に取得できる最大件数
+)
+
+// drawsSinceLocked 通し番号がsinceより後の抽選の記録を返す（room.Mutexを保持して呼び出すこと）
+func (room *Room) drawsSinceLocked(since int) []bingo.Draw {
+	drawn := room.Round.Game.Draws()
+	if since < 0 {
+		since = 0
+	}
+	if since >= len(drawn) {
+		return nil
+	}
+	draws := make([]bingo.Draw, len(drawn)-since)
+	copy(draws, drawn[since:])
+	return draws
+}
+
+// DrawHistory 現在のラウンドで通し番号がsinceより後の抽選の記録を最大limit件返す
+// ラウンド番号と、続きがあるかどうかも合わせて返す
+func (room *Room) DrawHistory(since, limit int) ([]bingo.Draw, int, int, bool) {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	draws := room.drawsSinceLocked(since)
+	hasMore := len(draws) > limit
+	if hasMore {
+		draws = draws[:limit]
+	}
+	return draws, room.Round.Number, len(room.Round.Game.Draws()), hasMore
+}
+
+// ルームの抽選履歴をJSONで返すハンドラー関数
+func (s *Server) DrawHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	password := query.Get("password")
+	if password == "" {
+		http.Error(w, "パスワードが提供されていません", http.StatusBadRequest)
+		return
+	}
+
+	// sinceより後の抽選を返す（省略時は最初から）
+	since := 0
+	if v := query.Get("since"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "sinceは0以上の整数で指定してください", http.StatusBadRequest)
+			return
+		}
+		since = n
+	}
+
+	limit := HistoryDefaultLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "limitは1以上の整数で指定してください", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, HistoryMaxLimit)
+	}
+
+	room := s.lookupRoom(w, r, password)
+	if room == nil {
+		return
+	}
+
+	draws, round, total, hasMore := room.DrawHistory(since, limit)
+	if draws == nil {
+		draws = []bingo.Draw{} // 空の場合もJSONでは配列として返す
+	}
+
+	resp := map[string]interface{}{
+		"round":   round,   // 現在のラウンド番号
+		"draws":   draws,   // 抽選の記録
+		"total":   total,   // これまでに引かれた数字の数
+		"hasMore": hasMore, // 続きがあるかどうか
+	}
+	if len(draws) > 0 {
+		resp["next"] = draws[len(draws)-1].Ordinal // 次のリクエストでsinceに指定する値
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"bingo/bingo"
+)
+
+// 勝ちに近いプレイヤーの一覧の件数に関する定数
+const (
+	LeaderboardEventSize = 10  // イベントで配信する件数
+	LeaderboardMaxLimit  = 100 // 一度に取得できる最大件数
+)
+
+// LeaderboardEntry 勝ちに近いプレイヤーの一覧の一行
+type LeaderboardEntry struct {
+	PlayerID   string `json:"playerId"`             // プレイヤーID
+	PlayerName string `json:"playerName,omitempty"` // 表示名（名前を伏せるルームでは空）
+	Missing    int    `json:"missing"`              // 現在の賞の形まで、最も近いカードであと何マスか
+	OneAway    int    `json:"oneAway"`              // あと一マスで揃う列の数（すべてのカードの合計）
+	Cards      int    `json:"cards"`                // 配られたカードの枚数
+}
+
+// leaderboardLocked 勝ちに近い順にプレイヤーを並べる（room.Mutexを保持して呼び出すこと）
+// limitが0以下の場合はすべて返す
+func (room *Room) leaderboardLocked(limit int) []LeaderboardEntry {
+	round := room.Round
+	entries := make([]LeaderboardEntry, 0, len(round.Cards))
+	for playerID, cards := range round.Cards {
+		if len(cards) == 0 {
+			continue
+		}
+		entry := LeaderboardEntry{PlayerID: playerID, Missing: 25, Cards: len(cards)}
+		if player := room.Players[playerID]; player != nil && !room.HideReachNames {
+			entry.PlayerName = player.Name
+		}
+		for _, card := range cards {
+			if card.Disqualified {
+				continue // 失格になったカードは数えない
+			}
+			marked := round.checkerFor(card).Marks(card.Card)
+			entry.Missing = min(entry.Missing, round.Game.Pattern.Missing(marked))
+			for _, missing := range bingo.LineMissing(marked) {
+				if missing == 1 {
+					entry.OneAway++
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	// 残りマスが少ない順、同じならリーチの列が多い順、さらに同じなら参加が早い順
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Missing != b.Missing {
+			return a.Missing < b.Missing
+		}
+		if a.OneAway != b.OneAway {
+			return a.OneAway > b.OneAway
+		}
+		return room.joinedBefore(a.PlayerID, b.PlayerID)
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// joinedBefore プレイヤーaがbより先に参加していればtrueを返す（room.Mutexを保持して呼び出すこと）
+func (room *Room) joinedBefore(a, b string) bool {
+	pa, pb := room.Players[a], room.Players[b]
+	if pa == nil || pb == nil || pa.JoinedAt.Equal(pb.JoinedAt) {
+		return a < b
+	}
+	return pa.JoinedAt.Before(pb.JoinedAt)
+}
+
+// Leaderboard 勝ちに近いプレイヤーの一覧を返す
+func (room *Room) Leaderboard(limit int) []LeaderboardEntry {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	return room.leaderboardLocked(limit)
+}
+
+// publishLeaderboardLocked 勝ちに近いプレイヤーの一覧をルームに通知する（room.Mutexを保持して呼び出すこと）
+func (room *Room) publishLeaderboardLocked() {
+	if len(room.Round.Cards) == 0 {
+		return // まだカードが配られていない
+	}
+	room.publishLocked(RoomEvent{Type: "leaderboard", Data: map[string]interface{}{
+		"round":   room.Round.Number,
+		"players": room.leaderboardLocked(LeaderboardEventSize),
+	}})
+}
+
+// 勝ちに近いプレイヤーの一覧を返すハンドラー関数
+// パスワードまたは閲覧専用コードでルームを指定する
+func (s *Server) LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := LeaderboardEventSize
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "limitは1以上の整数で指定してください", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, LeaderboardMaxLimit)
+	}
+
+	var room *Room
+	switch code, password := query.Get("code"), query.Get("password"); {
+	case code != "":
+		room = s.lookupRoomBy(w, r, code, s.rooms.GetRoomByViewCode)
+	case password != "":
+		room = s.lookupRoom(w, r, password)
+	default:
+		http.Error(w, "パスワードまたは閲覧用コードが提供されていません", http.StatusBadRequest)
+		return
+	}
+	if room == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"players": room.Leaderboard(limit)})
+}
@@ -0,0 +1,267 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// RoomState ルームの状態
+type RoomState string
+
+// ルームの状態の一覧
+const (
+	RoomLobby    RoomState = "lobby"    // 参加者の待機中（数字は引かれない）
+	RoomRunning  RoomState = "running"  // ゲーム進行中
+	RoomPaused   RoomState = "paused"   // 一時停止中
+	RoomFinished RoomState = "finished" // ゲーム終了（ルームは残る）
+	RoomClosed   RoomState = "closed"   // ルームが閉じられた
+)
+
+// ルームのライフサイクルに関する定数
+const (
+	RoomIdleTimeout    = 30 * time.Minute // 誰も接続していないルームを閉じるまでの既定の時間
+	RoomExpiryInterval = time.Minute      // 期限切れルームを確認する間隔
+)
+
+// 各状態から遷移できる状態の一覧
+var roomTransitions = map[RoomState][]RoomState{
+	RoomLobby:    {RoomRunning, RoomClosed},
+	RoomRunning:  {RoomPaused, RoomFinished, RoomClosed},
+	RoomPaused:   {RoomRunning, RoomFinished, RoomClosed},
+	RoomFinished: {RoomClosed}, // 次のゲームはNextRoundで準備する
+}
+
+// ホスト操作の名前と遷移先の状態の対応
+var roomActions = map[string]RoomState{
+	"start":  RoomRunning,
+	"pause":  RoomPaused,
+	"resume": RoomRunning,
+	"finish": RoomFinished,
+	"close":  RoomClosed,
+}
+
+// CurrentState ルームの現在の状態を返す
+func (room *Room) CurrentState() RoomState {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	return room.State
+}
+
+// Touch ルームの最終アクティビティ時刻を更新する
+func (room *Room) Touch() {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	room.LastActivity = room.now()
+}
+
+// Transition ルームの状態を遷移させる
+// 閉じる場合はRoomManager.CloseRoomを使用すること
+func (room *Room) Transition(to RoomState) error {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	return room.transitionLocked(to)
+}
+
+// transitionLocked ルームの状態を遷移させる（room.Mutexを保持して呼び出すこと）
+func (room *Room) transitionLocked(to RoomState) error {
+	if !canTransition(room.State, to) {
+		return fmt.Errorf("状態 %s から %s には遷移できません", room.State, to)
+	}
+
+	switch to {
+	case RoomRunning:
+		if room.State != RoomPaused || room.Countdown < 1 || room.Countdown > room.Interval {
+			room.Countdown = room.Interval // 再開時は残り時間を引き継ぐ（範囲外の場合はインターバルに戻す）
+		}
+		room.NextDraw = room.now().Add(time.Duration(room.Countdown) * time.Second)
+		room.startCountdownLocked()
+	case RoomPaused, RoomFinished, RoomClosed:
+		room.NextDraw = time.Time{}
+		room.stopCountdownLocked()
+	}
+
+	room.logger().Info("ルームの状態が変わりました", LogKeyEvent, "room_state", "from", room.State, "to", to)
+	room.State = to
+	room.LastActivity = room.now()
+	room.publishLocked(RoomEvent{Type: "state", Data: map[string]RoomState{"state": to}})
+	return nil
+}
+
+// 状態遷移が可能かどうかを確認する関数
+func canTransition(from, to RoomState) bool {
+	for _, next := range roomTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// stopCountdownLocked カウントダウンのゴルーチンを停止する（room.Mutexを保持して呼び出すこと）
+func (room *Room) stopCountdownLocked() {
+	if room.done != nil {
+		close(room.done)
+		room.done = nil
+	}
+}
+
+// CloseRoom ルームを閉じてゴルーチンを停止し、クライアントを切断してデータを削除する
+func (rm *RoomManager) CloseRoom(password, reason string) bool {
+	rm.Mutex.Lock()
+	room, exists := rm.Rooms[password]
+	if exists {
+		delete(rm.Rooms, password) // 新しい参加を受け付けないように先に登録を解除する
+		delete(rm.ViewCodes, room.ViewCode)
+	}
+	rm.Mutex.Unlock()
+
+	if !exists {
+		return false
+	}
+
+	room.Mutex.Lock()
+	room.stopCountdownLocked()
+	room.closeSubscribersLocked()
+	room.State = RoomClosed
+	clients := make([]*websocket.Conn, 0, len(room.Clients))
+	for conn := range room.Clients {
+		clients = append(clients, conn)
+	}
+	room.Clients = make(map[*websocket.Conn]*Client)
+	room.Mutex.Unlock()
+
+	// クライアントに通知して切断する
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, "room closed")
+	for _, conn := range clients {
+		conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+		conn.Close()
+	}
+
+	// ルームのデータファイルをラウンドごとに削除する
+	rounds := make([]int, 0, len(room.Rounds))
+	for _, round := range room.Rounds {
+		rounds = append(rounds, round.Number)
+	}
+	if err := rm.storage.DeleteRoom(room.ID, rounds); err != nil {
+		room.logger().Error("ルームのデータの削除に失敗しました", LogKeyEvent, "storage_delete_failed", "error", err)
+	}
+
+	room.logger().Info("ルームを閉じました", LogKeyEvent, "room_closed", "reason", reason, "clients", len(clients))
+	return true
+}
+
+// expireLoop 一定時間誰も接続していないルームを定期的に閉じるループ
+// quitが閉じられると終了する
+func (rm *RoomManager) expireLoop(quit <-chan struct{}) {
+	ticker := rm.clock.NewTicker(RoomExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+		case <-quit:
+			return
+		}
+
+		rm.closeIdleRooms(rm.clock.Now())
+	}
+}
+
+// closeIdleRooms 一定時間誰も接続・購読していないルームを閉じる
+// WebSocketのクライアントもSSEの購読者（大画面表示など）もいなくなってからの時間で判定する
+func (rm *RoomManager) closeIdleRooms(now time.Time) {
+	var expired []string
+	for _, room := range rm.ListRooms() {
+		room.Mutex.Lock()
+		watched := len(room.Clients) > 0 || len(room.subscribers) > 0
+		idle := !watched && now.Sub(room.LastActivity) > rm.config.Limits.RoomIdleTimeout
+		room.Mutex.Unlock()
+		if idle {
+			expired = append(expired, room.Password)
+		}
+	}
+
+	for _, password := range expired {
+		rm.CloseRoom(password, "idle")
+	}
+}
+
+// ListRooms 現在のルームの一覧をスナップショットとして返す
+func (rm *RoomManager) ListRooms() []*Room {
+	rm.Mutex.Lock()
+	defer rm.Mutex.Unlock()
+
+	rooms := make([]*Room, 0, len(rm.Rooms))
+	for _, room := range rm.Rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// ホストの操作（開始・一時停止・再開・終了・閉じる）を受け付けるハンドラー関数
+func (s *Server) RoomControlHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "無効なHTTPメソッド", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Password  string `json:"password"`  // ルームのパスワード
+		HostToken string `json:"hostToken"` // ルーム作成時に発行されたホスト用トークン
+		Action    string `json:"action"`    // 実行する操作
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.requestLogger(r).Warn("リクエストのデコードエラー", LogKeyEvent, "bad_request", "error", err)
+		http.Error(w, "リクエスト本文が無効です", http.StatusBadRequest)
+		return
+	}
+
+	to, ok := roomActions[req.Action]
+	if !ok {
+		http.Error(w, "不明な操作です", http.StatusBadRequest)
+		return
+	}
+
+	room := s.lookupRoom(w, r, req.Password)
+	if room == nil || !s.requireHost(w, r, room, req.HostToken) {
+		return
+	}
+
+	if to == RoomClosed {
+		s.rooms.CloseRoom(req.Password, "host")
+	} else if err := room.Transition(to); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	s.scheduler.Wake() // 抽選のスケジュールを再計算する
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"state": string(to)})
+}
+
+// IsHost トークンがルームのホスト用トークンと一致するかを確認する
+func (room *Room) IsHost(token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(room.HostToken)) == 1
+}
+
+// 推測されにくいトークンを生成する関数
+// 失敗した場合はloggerにエラーを出力して空文字列を返す
+func generateToken(logger *slog.Logger) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		logger.Error("トークンの生成に失敗しました", LogKeyEvent, "token_failed", "error", err)
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdleExpiryKeepsRoomsWithSubscribers(t *testing.T) {
+	s, clock, ts := newTestServer(t, nil)
+
+	watchedPassword, _ := createTestRoom(t, ts, map[string]interface{}{"interval": 5})
+	idlePassword, _ := createTestRoom(t, ts, map[string]interface{}{"interval": 5})
+	watched := s.Rooms().GetRoomByPassword(watchedPassword)
+	if err := watched.Transition(RoomRunning); err != nil {
+		t.Fatal(err)
+	}
+	events, _ := watched.Subscribe("") // SSE（大画面表示など）だけで見ているルーム
+
+	clock.Advance(40 * time.Minute)
+	s.rooms.closeIdleRooms(clock.Now())
+	if s.Rooms().GetRoomByPassword(watchedPassword) == nil {
+		t.Fatal("SSEで購読されているルームが閉じられました")
+	}
+	if s.Rooms().GetRoomByPassword(idlePassword) != nil {
+		t.Fatal("誰も接続していないルームが閉じられませんでした")
+	}
+
+	// 期限は最後の購読者が離れた時点から数える
+	watched.Unsubscribe(events)
+	clock.Advance(RoomIdleTimeout)
+	s.rooms.closeIdleRooms(clock.Now())
+	if s.Rooms().GetRoomByPassword(watchedPassword) == nil {
+		t.Fatal("購読者が離れた直後にルームが閉じられました")
+	}
+	clock.Advance(time.Second)
+	s.rooms.closeIdleRooms(clock.Now())
+	if s.Rooms().GetRoomByPassword(watchedPassword) != nil {
+		t.Fatal("購読者がいなくなってから期限が過ぎてもルームが閉じられませんでした")
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// ログの属性のキー
+// ログの集約先でルームやプレイヤーごとに絞り込めるよう、すべてのログで同じキーを使う
+const (
+	LogKeyRoom      = "room"       // ルームID（パスワードではない）
+	LogKeyPlayer    = "player"     // プレイヤーID
+	LogKeyRequestID = "request_id" // リクエストID
+	LogKeyEvent     = "event"      // ログの種類（room_created など）
+)
+
+// RequestIDHeader リクエストIDを受け渡すヘッダー
+const RequestIDHeader = "X-Request-ID"
+
+// ログに出力してはいけない値のキー（値を伏せて出力する）
+var secretLogKeys = map[string]bool{
+	"password":    true,
+	"hostToken":   true,
+	"playerToken": true,
+	"token":       true,
+	"viewCode":    true,
+	"adminToken":  true,
+}
+
+// 外部から受け取るリクエストIDとして許可する形式
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// NewLogger 出力レベルと形式（"text" または "json"）を指定してロガーを作成する
+// 秘密の値は伏せて出力する
+func NewLogger(w io.Writer, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       parseLogLevel(level),
+		ReplaceAttr: redactAttr,
+	}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// 出力レベルの文字列をslog.Levelに変換する関数（不明な場合はinfo）
+func parseLogLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// 秘密の値を伏せる関数
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if secretLogKeys[a.Key] {
+		return slog.String(a.Key, "[REDACTED]")
+	}
+	return a
+}
+
+// logger ルームIDを付けたロガーを返す
+func (room *Room) logger() *slog.Logger {
+	return room.manager.logger.With(LogKeyRoom, room.ID)
+}
+
+// loggerKey リクエストのコンテキストにロガーを保持するためのキー
+type loggerKey struct{}
+
+// withRequestLogger リクエストIDを割り当て、リクエストIDを付けたロガーをコンテキストに保持する
+// クライアントが有効なX-Request-IDを送った場合はそれを引き継ぐ
+func (s *Server) withRequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = generateSecretCode(s.logger, 16) // 公開するIDの乱数列を推測されないように暗号論的乱数を使う
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		logger := s.logger.With(LogKeyRequestID, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), loggerKey{}, logger)))
+	})
+}
+
+// requestLogger リクエストに対応するロガーを返す
+func (s *Server) requestLogger(r *http.Request) *slog.Logger {
+	if logger, ok := r.Context().Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return s.logger
+}
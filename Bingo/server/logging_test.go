@@ -0,0 +1,82 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncBuffer 複数のゴルーチンから書き込めるログの出力先
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write ログを書き込む
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// entries 出力されたJSONのログを一行ずつ返す
+func (b *syncBuffer) entries(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("ログを読み取れませんでした: %v: %s", err, line)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestRequestLogsIncludeRequestAndPlayer(t *testing.T) {
+	s, _, ts := newTestServer(t, nil)
+	var logs syncBuffer
+	s.logger = slog.New(slog.NewJSONHandler(&logs, nil))
+	password, _ := createTestRoom(t, ts, map[string]interface{}{"interval": 5})
+
+	// 参加と申告のリクエストにリクエストIDを付ける
+	post := func(path, requestID string, body interface{}) map[string]interface{} {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+path, bytes.NewReader(data))
+		req.Header.Set(RequestIDHeader, requestID)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&out)
+		return out
+	}
+	joined := post("/join-room", "join-1", map[string]string{"password": password})
+	post("/check-bingo", "claim-1", map[string]string{"password": password, "playerToken": joined["playerToken"].(string), "cardId": "none"})
+
+	room := s.Rooms().GetRoomByPassword(password)
+	want := map[string]string{"player_joined": "join-1", "claim_rejected": "claim-1"}
+	for _, entry := range logs.entries(t) {
+		requestID, ok := want[entry[LogKeyEvent].(string)]
+		if !ok {
+			continue
+		}
+		if entry[LogKeyRequestID] != requestID || entry[LogKeyRoom] != room.ID || entry[LogKeyPlayer] != joined["playerId"] {
+			t.Fatalf("%s のログにリクエストID・ルーム・プレイヤーがありません: %v", entry[LogKeyEvent], entry)
+		}
+		delete(want, entry[LogKeyEvent].(string))
+	}
+	if len(want) > 0 {
+		t.Fatalf("出力されなかったログがあります: %v", want)
+	}
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Counter 増え続ける値
+type Counter struct {
+	v atomic.Uint64
+}
+
+// Inc 1増やす
+func (c *Counter) Inc() {
+	c.v.Add(1)
+}
+
+// Value 現在の値を返す
+func (c *Counter) Value() uint64 {
+	return c.v.Load()
+}
+
+// Histogram 観測値をバケットごとに数える
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64 // バケットの上限（昇順）
+	counts  []uint64  // バケットごとの観測数（累積ではない）
+	count   uint64    // 観測数の合計
+	sum     float64   // 観測値の合計
+}
+
+// 新しいHistogramインスタンスを作成
+func NewHistogram(buckets []float64) *Histogram {
+	return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// Observe 観測値を記録する
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// HistogramVec ラベルの値ごとのHistogram
+type HistogramVec struct {
+	mu      sync.Mutex
+	buckets []float64
+	byLabel map[string]*Histogram
+}
+
+// 新しいHistogramVecインスタンスを作成
+func NewHistogramVec(buckets []float64) *HistogramVec {
+	return &HistogramVec{buckets: buckets, byLabel: make(map[string]*Histogram)}
+}
+
+// With ラベルの値に対応するHistogramを返す
+func (hv *HistogramVec) With(label string) *Histogram {
+	hv.mu.Lock()
+	defer hv.mu.Unlock()
+
+	h, exists := hv.byLabel[label]
+	if !exists {
+		h = NewHistogram(hv.buckets)
+		hv.byLabel[label] = h
+	}
+	return h
+}
+
+// 遅延を計測するバケット（秒）
+var latencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// Metrics構造体 サーバー全体の計測値
+type Metrics struct {
+	Draws              Counter       // 引かれた数字の数
+	ClaimsValid        Counter       // 確認できたビンゴの申告の数
+	ClaimsInvalid      Counter       // お手つきの数
+	Joins              Counter       // プレイヤーの参加の数
+	JoinFailures       Counter       // 参加に失敗した数（存在しないルームなど）
+	JoinLockouts       Counter       // 失敗が続いたためにIPまたはIPとルームの組をロックアウトした数
+	SlowClientsDropped Counter       // 受信が遅いため切断したクライアントの数
+	Broadcast          *Histogram    // イベントをルームの購読者全員に配るのにかかった時間
+	HTTP               *HistogramVec // エンドポイントごとのハンドラーの処理時間
+}
+
+// 新しいMetricsインスタンスを作成
+func NewMetrics() *Metrics {
+	return &Metrics{
+		Broadcast: NewHistogram(latencyBuckets),
+		HTTP:      NewHistogramVec(latencyBuckets),
+	}
+}
+
+// 処理時間を計測しないエンドポイント（接続を保ち続けるため）
+var streamingPaths = map[string]bool{
+	"/ws":               true,
+	"/get-room-numbers": true,
+	"/board-feed":       true,
+}
+
+// withMetrics ハンドラーの処理時間をエンドポイントごとに計測する
+func (s *Server) withMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := s.mux.Handler(r) // 登録したパターンをラベルにする（静的ファイルは "/"）
+		if streamingPaths[pattern] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if pattern == "" {
+			pattern = "unmatched"
+		}
+
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		s.metrics.HTTP.With(pattern).Observe(time.Since(start).Seconds())
+	})
+}
+
+// MetricsHandler Prometheusのテキスト形式で計測値を返すハンドラー関数
+func (s *Server) MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "無効なHTTPメソッド", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.writeMetrics(w)
+}
+
+// writeMetrics 計測値とルームの現在の状態を書き込む
+func (s *Server) writeMetrics(w io.Writer) {
+	m := s.metrics
+
+	// ルームの状態ごとの数・接続数・参加者数はその時点のルームから集計する
+	states := map[RoomState]int{RoomLobby: 0, RoomRunning: 0, RoomPaused: 0, RoomFinished: 0}
+	clients := 0
+	type roomPlayers struct {
+		id      string
+		players int
+	}
+	var perRoom []roomPlayers
+	for _, room := range s.rooms.ListRooms() {
+		room.Mutex.Lock()
+		states[room.State]++
+		clients += len(room.Clients)
+		perRoom = append(perRoom, roomPlayers{room.ID, len(room.Players)})
+		room.Mutex.Unlock()
+	}
+	sort.Slice(perRoom, func(i, j int) bool { return perRoom[i].id < perRoom[j].id })
+
+	writeHeader(w, "bingo_rooms_active", "gauge", "現在のルームの数（状態ごと）")
+	for _, state := range []RoomState{RoomLobby, RoomRunning, RoomPaused, RoomFinished} {
+		fmt.Fprintf(w, "bingo_rooms_active{state=%q} %d\n", state, states[state])
+	}
+	writeHeader(w, "bingo_clients_connected", "gauge", "接続中のWebSocketクライアントの数")
+	fmt.Fprintf(w, "bingo_clients_connected %d\n", clients)
+	writeHeader(w, "bingo_room_players", "gauge", "ルームごとの参加プレイヤーの数")
+	for _, room := range perRoom {
+		fmt.Fprintf(w, "bingo_room_players{room=%q} %d\n", room.id, room.players)
+	}
+
+	writeCounter(w, "bingo_draws_total", "引かれた数字の数", &m.Draws)
+	writeHeader(w, "bingo_claims_total", "counter", "ビンゴの申告の数（結果ごと）")
+	fmt.Fprintf(w, "bingo_claims_total{result=\"valid\"} %d\n", m.ClaimsValid.Value())
+	fmt.Fprintf(w, "bingo_claims_total{result=\"invalid\"} %d\n", m.ClaimsInvalid.Value())
+	writeCounter(w, "bingo_joins_total", "プレイヤーの参加の数", &m.Joins)
+	writeCounter(w, "bingo_join_failures_total", "参加に失敗した数", &m.JoinFailures)
+	writeCounter(w, "bingo_join_lockouts_total", "失敗が続いたためにロックアウトした数", &m.JoinLockouts)
+	writeCounter(w, "bingo_slow_clients_dropped_total", "受信が遅いため切断したクライアントの数", &m.SlowClientsDropped)
+
+	writeHeader(w, "bingo_broadcast_duration_seconds", "histogram", "イベントをルームの購読者全員に配るのにかかった時間")
+	m.Broadcast.write(w, "bingo_broadcast_duration_seconds", "")
+
+	writeHeader(w, "bingo_http_request_duration_seconds", "histogram", "エンドポイントごとのハンドラーの処理時間")
+	m.HTTP.mu.Lock()
+	handlers := make([]string, 0, len(m.HTTP.byLabel))
+	for handler := range m.HTTP.byLabel {
+		handlers = append(handlers, handler)
+	}
+	m.HTTP.mu.Unlock()
+	sort.Strings(handlers)
+	for _, handler := range handlers {
+		m.HTTP.With(handler).write(w, "bingo_http_request_duration_seconds", fmt.Sprintf("handler=%q", handler))
+	}
+}
+
+// HELPとTYPEの行を書き込む関数
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// ラベルのないカウンターを書き込む関数
+func writeCounter(w io.Writer, name, help string, c *Counter) {
+	writeHeader(w, name, "counter", help)
+	fmt.Fprintf(w, "%s %d\n", name, c.Value())
+}
+
+// write バケット・合計・観測数の行を書き込む（labelsは "key=\"value\"" の形式）
+func (h *Histogram) write(w io.Writer, name, labels string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	withLabels := func(extra string) string {
+		all := strings.Trim(labels+","+extra, ",")
+		if all == "" {
+			return ""
+		}
+		return "{" + all + "}"
+	}
+
+	var cumulative uint64
+	for i, upper := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabels(fmt.Sprintf("le=%q", formatFloat(upper))), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabels(`le="+Inf"`), h.count)
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, withLabels(""), formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, withLabels(""), h.count)
+}
+
+// Prometheusの形式で浮動小数点数を書く関数
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
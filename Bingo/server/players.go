@@ -0,0 +1,314 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"bingo/bingo"
+
+	"github.com/gorilla/websocket"
+)
+
+// プレイヤーとチャットに関する定数
+const (
+	MaxPlayerNameLength = 20  // プレイヤー名の最大文字数
+	MaxChatLength       = 200 // チャットの最大文字数
+)
+
+// Role ルームでの参加者の役割
+type Role string
+
+// 参加者の役割の一覧
+const (
+	RolePlayer    Role = "player"    // カードを持ってビンゴを申告できる参加者
+	RoleSpectator Role = "spectator" // 閲覧専用コードで参加した観戦者（カード・申告・チャット不可）
+)
+
+// Client WebSocketで接続しているクライアントの情報
+type Client struct {
+	Role     Role           // 参加者の役割
+	PlayerID string         // プレイヤーの場合はプレイヤーID
+	Host     bool           // ホスト用トークンで接続したか（ホスト向けの通知を受け取る）
+	direct   chan RoomEvent // このクライアントだけに送るイベント
+	kicked   chan struct{}  // 退出させられたときに閉じられるチャネル
+}
+
+// Player ルームに参加しているプレイヤー
+type Player struct {
+	ID       string    `json:"id"`       // プレイヤーID（公開してよい識別子）
+	Name     string    `json:"name"`     // 表示名
+	Token    string    `json:"-"`        // 本人確認用のトークン（本人にのみ返す）
+	JoinedAt time.Time `json:"joinedAt"` // 参加した時刻
+
+	CooldownUntil time.Time `json:"-"` // お手つきのため申告できない期限
+}
+
+// IssuedCard プレイヤーに配られたビンゴカード
+type IssuedCard struct {
+	ID       string     `json:"id"`       // カードID
+	Card     bingo.Card `json:"card"`     // カードの数字
+	IssuedAt time.Time  `json:"issuedAt"` // 配られた時刻
+
+	// 配られた時点で既に引かれていた数字の数（これより後に引かれた数字だけで判定する）
+	IssuedAfter int `json:"issuedAfter"`
+
+	Disqualified bool `json:"disqualified,omitempty"` // お手つきで失格になったか
+}
+
+// RosterEntry 参加者一覧の一人分
+type RosterEntry struct {
+	ID        string `json:"id"`        // プレイヤーID
+	Name      string `json:"name"`      // 表示名
+	Connected bool   `json:"connected"` // WebSocketで接続中かどうか
+}
+
+// ChatMessage チャットのメッセージ
+type ChatMessage struct {
+	PlayerID string    `json:"playerId"` // 送信したプレイヤーID
+	Name     string    `json:"name"`     // 送信したプレイヤーの表示名
+	Text     string    `json:"text"`     // 本文
+	Time     time.Time `json:"time"`     // 送信時刻
+}
+
+// AddPlayer ルームに新しいプレイヤーを登録する
+// 参加者数が上限に達している場合はErrTooManyPlayersを返す
+func (room *Room) AddPlayer(name string) (*Player, error) {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	if err := room.checkPlayerCapacityLocked(); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > MaxPlayerNameLength {
+		name = string([]rune(name)[:MaxPlayerNameLength])
+	}
+	if name == "" {
+		name = fmt.Sprintf("プレイヤー%d", len(room.Players)+1)
+	}
+
+	player := &Player{
+		ID:       generatePassword(room.manager.rng, 8),
+		Name:     name,
+		Token:    generateToken(room.logger()),
+		JoinedAt: room.now(),
+	}
+	for room.Players[player.ID] != nil {
+		player.ID = generatePassword(room.manager.rng, 8) // 既存のプレイヤーと重複した場合は再生成
+	}
+	room.Players[player.ID] = player
+	room.LastActivity = room.now()
+	room.publishRosterLocked()
+	return player, nil // 参加の計測とログは、参加が確定した後に呼び出し側で行う
+}
+
+// RemovePlayer 接続する前に登録を取り消すプレイヤーを削除する
+// WebSocketの接続がルームに追加できなかった場合に、登録だけが残らないように使う
+func (room *Room) RemovePlayer(playerID string) {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	if room.Players[playerID] == nil {
+		return
+	}
+	delete(room.Players, playerID)
+	delete(room.Round.Cards, playerID)
+	room.publishRosterLocked()
+}
+
+// PlayerByToken トークンに対応するプレイヤーを返す（見つからない場合はnil）
+func (room *Room) PlayerByToken(token string) *Player {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	return room.playerByTokenLocked(token)
+}
+
+// playerByTokenLocked トークンに対応するプレイヤーを返す（room.Mutexを保持して呼び出すこと）
+func (room *Room) playerByTokenLocked(token string) *Player {
+	if token == "" {
+		return nil
+	}
+	for _, player := range room.Players {
+		if subtle.ConstantTimeCompare([]byte(player.Token), []byte(token)) == 1 {
+			return player
+		}
+	}
+	return nil
+}
+
+// IssueCard 現在のラウンドのビンゴカードをプレイヤーに配る
+// 一つのラウンドで配れる枚数の上限に達している場合はErrTooManyCardsを返す
+func (room *Room) IssueCard(player *Player) (*IssuedCard, error) {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	if len(room.Round.Cards[player.ID]) >= room.manager.config.Limits.MaxCardsPerPlayer {
+		return nil, ErrTooManyCards
+	}
+
+	card := &IssuedCard{
+		ID:       generatePassword(room.manager.rng, 8),
+		Card:     bingo.NewCard(room.manager.deckRng),
+		IssuedAt: room.now(),
+
+		IssuedAfter: len(room.Round.Game.Draws()),
+	}
+	room.Round.Cards[player.ID] = append(room.Round.Cards[player.ID], card)
+	room.LastActivity = room.now()
+	return card, nil
+}
+
+// AddClient WebSocket接続をルームに追加する
+// 接続数が上限に達している場合はErrRoomFullを返す
+func (room *Room) AddClient(conn *websocket.Conn, client *Client) error {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	if err := room.checkClientCapacityLocked(); err != nil {
+		return err
+	}
+
+	room.Clients[conn] = client
+	room.LastActivity = room.now()
+	if client.Role == RolePlayer {
+		room.publishRosterLocked()
+	}
+	return nil
+}
+
+// RemoveClient WebSocket接続をルームから削除する
+func (room *Room) RemoveClient(conn *websocket.Conn) {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	client, exists := room.Clients[conn]
+	if !exists {
+		return
+	}
+	delete(room.Clients, conn)
+	if client.Role == RolePlayer {
+		room.publishRosterLocked()
+	}
+}
+
+// KickPlayer プレイヤーをルームから退出させ、接続中のクライアントを切断する
+// プレイヤーのトークンと現在のラウンドのカード、保留中の申告は無効になる。見つからない場合はfalseを返す
+func (room *Room) KickPlayer(playerID string) bool {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	if room.Players[playerID] == nil {
+		return false
+	}
+	delete(room.Players, playerID)
+	delete(room.Round.Cards, playerID)
+	room.Round.pending.removePlayer(playerID) // 退出させたプレイヤーが締め切り後に勝者にならないようにする
+
+	for conn, client := range room.Clients {
+		if client.Role != RolePlayer || client.PlayerID != playerID {
+			continue
+		}
+		client.send(RoomEvent{Type: "kicked", Data: map[string]string{"message": "ルームから退出させられました"}})
+		if client.kicked != nil {
+			close(client.kicked) // 書き込み用のゴルーチンが通知を送ってから接続を閉じる
+		}
+		delete(room.Clients, conn)
+	}
+
+	room.publishRosterLocked()
+	room.publishLeaderboardLocked()
+	return true // 退出のログはリクエストIDを付けて呼び出し側で出力する
+}
+
+// Roster 参加者一覧を返す
+func (room *Room) Roster() []RosterEntry {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	return room.rosterLocked()
+}
+
+// rosterLocked 参加者一覧を作成する（room.Mutexを保持して呼び出すこと）
+func (room *Room) rosterLocked() []RosterEntry {
+	connected := make(map[string]bool)
+	for _, client := range room.Clients {
+		if client.Role == RolePlayer {
+			connected[client.PlayerID] = true
+		}
+	}
+
+	players := make([]*Player, 0, len(room.Players))
+	for _, player := range room.Players {
+		players = append(players, player)
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].JoinedAt.Before(players[j].JoinedAt) })
+
+	roster := make([]RosterEntry, 0, len(players))
+	for _, player := range players {
+		roster = append(roster, RosterEntry{ID: player.ID, Name: player.Name, Connected: connected[player.ID]})
+	}
+	return roster
+}
+
+// publishRosterLocked 参加者一覧をルームに配信する（room.Mutexを保持して呼び出すこと）
+func (room *Room) publishRosterLocked() {
+	room.publishLocked(RoomEvent{Type: "roster", Data: room.rosterLocked()})
+}
+
+// handleClientMessage クライアントから受け取ったメッセージを処理する
+func (room *Room) handleClientMessage(client *Client, msg clientMessage) {
+	switch msg.Type {
+	case "chat":
+		if client.Role != RolePlayer {
+			client.send(errorEvent("観戦者はチャットできません"))
+			return
+		}
+		text := strings.TrimSpace(msg.Text)
+		if text == "" {
+			return
+		}
+		if utf8.RuneCountInString(text) > MaxChatLength {
+			text = string([]rune(text)[:MaxChatLength])
+		}
+
+		room.Mutex.Lock()
+		defer room.Mutex.Unlock()
+		player := room.Players[client.PlayerID]
+		if player == nil {
+			return
+		}
+		room.publishLocked(RoomEvent{Type: "chat", Data: ChatMessage{
+			PlayerID: player.ID,
+			Name:     player.Name,
+			Text:     text,
+			Time:     room.now(),
+		}})
+	default:
+		// 未対応のメッセージは無視する
+	}
+}
+
+// send このクライアントだけにイベントを送る（送信待ちが溢れている場合は破棄する）
+func (client *Client) send(ev RoomEvent) {
+	select {
+	case client.direct <- ev:
+	default:
+	}
+}
+
+// エラーを通知するイベントを作成する関数
+func errorEvent(message string) RoomEvent {
+	return RoomEvent{Type: "error", Data: map[string]string{"message": message}}
+}
+
+// クライアントから受け取るメッセージの構造体
+type clientMessage struct {
+	Type string `json:"type"` // メッセージの種類（chat など）
+	Text string `json:"text"` // チャットの本文
+}
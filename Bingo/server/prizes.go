@@ -0,0 +1,115 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"bingo/bingo"
+)
+
+// ラウンドに設定できる賞の段階の上限
+const MaxPrizeStages = 10
+
+// 賞に関するエラー
+var (
+	ErrPrizesAwarded = errors.New("このラウンドの賞はすべて決まりました")
+)
+
+// Prize ラウンド内の一つの賞（段階）
+// 例: 「一列揃えで先着3名」→「全マスで先着1名」
+type Prize struct {
+	Name    string        `json:"name"`    // 賞の名前（省略時は形の名前）
+	Pattern bingo.Pattern `json:"pattern"` // この賞で勝ちとなる形
+	Slots   int           `json:"slots"`   // 賞の枠数（0の場合は無制限で、ラウンドは自動で終わらない）
+}
+
+// 賞を指定しなかった場合の設定（一列揃えで人数無制限）
+func defaultPrizes(pattern bingo.Pattern) []Prize {
+	return []Prize{{Name: string(pattern), Pattern: pattern}}
+}
+
+// 賞の設定を確認して、省略された名前を補う関数
+func normalizePrizes(prizes []Prize) ([]Prize, error) {
+	if len(prizes) == 0 || len(prizes) > MaxPrizeStages {
+		return nil, fmt.Errorf("賞は1から%d段階までで指定してください", MaxPrizeStages)
+	}
+
+	normalized := make([]Prize, len(prizes))
+	for i, prize := range prizes {
+		if !prize.Pattern.Valid() {
+			return nil, ErrInvalidPattern
+		}
+		if prize.Slots < 0 {
+			return nil, errors.New("賞の枠数は0以上で指定してください")
+		}
+		// 枠数が無制限の賞は次の段階に進まないため、最後の段階にのみ指定できる
+		if prize.Slots == 0 && i < len(prizes)-1 {
+			return nil, errors.New("枠数が無制限の賞は最後の段階にのみ指定できます")
+		}
+		if prize.Name == "" {
+			prize.Name = string(prize.Pattern)
+		}
+		normalized[i] = prize
+	}
+	return normalized, nil
+}
+
+// CurrentPrize 現在の段階の賞を返す（すべての賞が決まっている場合はnil）
+func (round *Round) CurrentPrize() *Prize {
+	if round.Stage >= len(round.Prizes) {
+		return nil
+	}
+	return &round.Prizes[round.Stage]
+}
+
+// stageWinners 指定した段階の勝者の数を返す
+func (round *Round) stageWinners(stage int) int {
+	count := 0
+	for _, win := range round.Winners {
+		if win.Stage == stage {
+			count++
+		}
+	}
+	return count
+}
+
+// awardLocked 勝者を記録したあと、賞の枠が埋まっていれば次の段階に進める（room.Mutexを保持して呼び出すこと）
+// 最後の賞の枠が埋まった場合はラウンドを終える
+func (room *Room) awardLocked() {
+	round := room.Round
+	prize := round.CurrentPrize()
+	if prize == nil || prize.Slots == 0 || round.stageWinners(round.Stage) < prize.Slots {
+		return
+	}
+
+	round.Stage++
+	if next := round.CurrentPrize(); next != nil {
+		round.Game.Pattern = next.Pattern
+		round.Reach = make(map[string]string) // 形が変わるのでリーチを数え直す
+		room.publishLocked(RoomEvent{Type: "prize", Data: map[string]interface{}{
+			"round":   round.Number,
+			"stage":   round.Stage,
+			"prize":   next.Name,
+			"pattern": next.Pattern,
+			"slots":   next.Slots,
+		}})
+		room.updateReachLocked()
+		room.publishLeaderboardLocked()
+		room.logger().Info("次の賞に進みました", LogKeyEvent, "prize_advanced", "round", round.Number, "prize", next.Name)
+		return
+	}
+
+	// すべての賞が決まったのでラウンドを終える
+	now := room.now()
+	round.EndedAt = &now
+	room.publishLocked(RoomEvent{Type: "round_end", Data: map[string]interface{}{
+		"round":   round.Number,
+		"winners": round.Winners,
+	}})
+	if canTransition(room.State, RoomFinished) {
+		if err := room.transitionLocked(RoomFinished); err != nil {
+			room.logger().Error("ラウンドの終了に失敗しました", LogKeyEvent, "round_finish_failed", "round", round.Number, "error", err)
+		}
+	}
+	room.logger().Info("すべての賞が決まりラウンドを終えました", LogKeyEvent, "round_end", "round", round.Number)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"math/rand"
+	"sync"
+)
+
+// コードに使う文字
+const codeCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// lockedSource 複数のゴルーチンから使えるようにミューテックスで保護した乱数源
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+// Int63 乱数を返す
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.src.Int63()
+}
+
+// Seed 乱数源を初期化する
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.src.Seed(seed)
+}
+
+// 乱数源をゴルーチンから安全に使える乱数生成器にする関数
+func newLockedRand(src rand.Source) *rand.Rand {
+	return rand.New(&lockedSource{src: src})
+}
+
+// 暗号論的乱数で初期化した乱数源を作成する関数（抽選の順番を推測されないように使う）
+func newSecretSource() (rand.Source, error) {
+	var seed [8]byte
+	if _, err := crand.Read(seed[:]); err != nil {
+		return nil, fmt.Errorf("乱数源の初期化に失敗しました: %v", err)
+	}
+	return rand.NewSource(int64(binary.LittleEndian.Uint64(seed[:]))), nil
+}
+
+// ランダムな英数字のコードを生成する関数（公開してよいIDに使用）
+func generatePassword(rng *rand.Rand, length int) string {
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = codeCharset[rng.Intn(len(codeCharset))]
+	}
+	return string(b)
+}
+
+// 推測されにくい英数字のコードを暗号論的乱数で生成する関数（パスワードや閲覧専用コードに使用）
+// 失敗した場合はloggerにエラーを出力して空文字列を返す
+func generateSecretCode(logger *slog.Logger, length int) string {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(codeCharset)))
+	for i := range b {
+		n, err := crand.Int(crand.Reader, max)
+		if err != nil {
+			logger.Error("コードの生成に失敗しました", LogKeyEvent, "code_failed", "error", err)
+			return ""
+		}
+		b[i] = codeCharset[n.Int64()]
+	}
+	return string(b)
+}
@@ -0,0 +1,314 @@
+package server
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// 参加試行のレート制限に関する定数
+const (
+	JoinBaseDelay       = time.Second      // 1回目の失敗後の待機時間
+	JoinMaxDelay        = time.Minute      // バックオフの最大待機時間
+	JoinMaxFailures     = 8                // ロックアウトまでの既定の連続失敗回数
+	JoinLockoutDuration = 15 * time.Minute // ロックアウトの既定の継続時間
+)
+
+// JoinLimiter構造体 参加試行をIPごと・IPとルームの組ごとに制限する
+// パスワードの推測はIPごとに、ルーム内のトークンの推測はIPとルームの組ごとに数える
+type JoinLimiter struct {
+	Mutex   sync.Mutex             // entriesへのアクセスを同期するためのミューテックス
+	entries map[string]*limitEntry // キー（"ip:..." または "token:..."）ごとの試行状態
+	clock   Clock                  // 現在時刻を返す時計
+	limits  Limits                 // 失敗回数やロックアウトの設定
+	metrics *Metrics               // ロックアウトの数を記録する計測値
+	logger  *slog.Logger           // ログの出力先
+}
+
+// キーごとの試行状態
+type limitEntry struct {
+	failures     int       // 連続失敗回数
+	lastFailure  time.Time // 最後に失敗した時刻
+	blockedUntil time.Time // この時刻まで試行を拒否する
+	lastSeen     time.Time // 最後に試行された時刻
+}
+
+// 新しいJoinLimiterインスタンスを作成
+func NewJoinLimiter(clock Clock, limits Limits, metrics *Metrics, logger *slog.Logger) *JoinLimiter {
+	return &JoinLimiter{
+		entries: make(map[string]*limitEntry),
+		clock:   clock,
+		limits:  limits,
+		metrics: metrics,
+		logger:  logger,
+	}
+}
+
+// IPの試行状態のキーを返す関数
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// IPとルームの組でトークンの試行状態のキーを返す関数
+func tokenKey(ip, roomID string) string {
+	return "token:" + roomID + ":" + ip
+}
+
+// entry キーに対応する試行状態を取得する（存在しない場合は作成する）
+func (jl *JoinLimiter) entry(key string, now time.Time) *limitEntry {
+	e, exists := jl.entries[key]
+	if !exists {
+		e = &limitEntry{}
+		jl.entries[key] = e
+	}
+	e.lastSeen = now
+	return e
+}
+
+// blockedLocked キーがバックオフ中またはロックアウト中であれば残りの待機時間を返す（jl.Mutexを保持して呼び出すこと）
+// 推測されたコードごとに状態を作らないように既存の状態だけを見る
+func (jl *JoinLimiter) blockedLocked(key string, now time.Time) (time.Duration, bool) {
+	e, exists := jl.entries[key]
+	if !exists || !now.Before(e.blockedUntil) {
+		return 0, false
+	}
+	e.lastSeen = now
+	return e.blockedUntil.Sub(now), true
+}
+
+// AllowIP IPがバックオフ中またはロックアウト中でないかを確認する
+// 許可されない場合は再試行までの待機時間を返す
+func (jl *JoinLimiter) AllowIP(ip string) (bool, time.Duration) {
+	jl.Mutex.Lock()
+	defer jl.Mutex.Unlock()
+
+	if retryAfter, blocked := jl.blockedLocked(ipKey(ip), jl.clock.Now()); blocked {
+		return false, retryAfter
+	}
+	return true, 0
+}
+
+// Allow IPと、そのIPによるルームでのトークンの試行の両方が許可されているかを確認する
+// 他のIPの失敗ではルームへの参加は制限されない。許可されない場合は再試行までの待機時間を返す
+func (jl *JoinLimiter) Allow(ip, roomID string) (bool, time.Duration) {
+	jl.Mutex.Lock()
+	defer jl.Mutex.Unlock()
+
+	now := jl.clock.Now()
+	for _, key := range []string{ipKey(ip), tokenKey(ip, roomID)} {
+		if retryAfter, blocked := jl.blockedLocked(key, now); blocked {
+			return false, retryAfter
+		}
+	}
+	return true, 0
+}
+
+// RecordFailure パスワードの不一致などIPごとの参加失敗を記録し、指数バックオフまたはロックアウトを設定する
+func (jl *JoinLimiter) RecordFailure(ip string) {
+	jl.Mutex.Lock()
+	defer jl.Mutex.Unlock()
+
+	if jl.recordLocked(ipKey(ip), jl.clock.Now()) {
+		jl.logger.Warn("参加の失敗が続いたためロックアウトしました", LogKeyEvent, "ip_lockout", "ip", ip, "until", jl.entries[ipKey(ip)].blockedUntil)
+	}
+}
+
+// RecordTokenFailure ルームでのトークンの不一致を記録し、IPとルームの組に指数バックオフまたはロックアウトを設定する
+func (jl *JoinLimiter) RecordTokenFailure(ip, roomID string) {
+	jl.Mutex.Lock()
+	defer jl.Mutex.Unlock()
+
+	key := tokenKey(ip, roomID)
+	if jl.recordLocked(key, jl.clock.Now()) {
+		jl.logger.Warn("トークンの不一致が続いたためロックアウトしました", LogKeyEvent, "token_lockout", LogKeyRoom, roomID, "ip", ip, "until", jl.entries[key].blockedUntil)
+	}
+}
+
+// recordLocked 失敗を記録して待機時間を設定する（jl.Mutexを保持して呼び出すこと）
+// ロックアウトした場合はtrueを返す
+func (jl *JoinLimiter) recordLocked(key string, now time.Time) bool {
+	e := jl.entry(key, now)
+	if now.Sub(e.lastFailure) > jl.limits.JoinLockout {
+		e.failures = 0 // しばらく失敗していなければ数え直す（成功しても数え直さない）
+	}
+	e.failures++
+	e.lastFailure = now
+
+	if e.failures >= jl.limits.JoinMaxFailures {
+		e.blockedUntil = now.Add(jl.limits.JoinLockout)
+		e.failures = 0
+		jl.metrics.JoinLockouts.Inc()
+		return true
+	}
+
+	// 失敗回数に応じて待機時間を倍にしていく
+	delay := JoinBaseDelay << (e.failures - 1)
+	if delay > JoinMaxDelay {
+		delay = JoinMaxDelay
+	}
+	e.blockedUntil = now.Add(delay)
+	return false
+}
+
+// pruneLoop 使われなくなった試行状態を定期的に削除するループ
+// quitが閉じられると終了する
+func (jl *JoinLimiter) pruneLoop(quit <-chan struct{}) {
+	ticker := jl.clock.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+		case <-quit:
+			return
+		}
+
+		jl.Mutex.Lock()
+		now := jl.clock.Now()
+		for key, e := range jl.entries {
+			if now.After(e.blockedUntil) && now.Sub(e.lastSeen) > jl.limits.JoinLockout {
+				delete(jl.entries, key)
+			}
+		}
+		jl.Mutex.Unlock()
+	}
+}
+
+// 失敗の多いIPを制限しながらパスワードに対応するルームを取得する関数
+// ルームが見つからない場合はエラーレスポンスを書き込んでnilを返す
+func (s *Server) lookupRoom(w http.ResponseWriter, r *http.Request, password string) *Room {
+	return s.lookupRoomBy(w, r, password, s.rooms.GetRoomByPassword)
+}
+
+// 失敗の多いIPを制限しながら指定された方法でコードに対応するルームを取得する関数
+func (s *Server) lookupRoomBy(w http.ResponseWriter, r *http.Request, code string, find func(string) *Room) *Room {
+	ip := s.clientIP(r)
+	if ok, retryAfter := s.limiter.AllowIP(ip); !ok {
+		writeRateLimited(w, retryAfter)
+		return nil
+	}
+
+	room := find(code)
+	if room == nil {
+		s.limiter.RecordFailure(ip)
+		s.metrics.JoinFailures.Inc()
+		s.requestLogger(r).Info("ルームが見つかりませんでした", LogKeyEvent, "join_failed", "ip", ip)
+		http.Error(w, "ルームが見つかりませんでした", http.StatusNotFound)
+		return nil
+	}
+	return room
+}
+
+// ルームとプレイヤーを取得する関数
+// プレイヤーとして認証できない場合はエラーレスポンスを書き込んでnilを返す
+func (s *Server) lookupPlayer(w http.ResponseWriter, r *http.Request, password, token string) (*Room, *Player) {
+	room := s.lookupRoom(w, r, password)
+	if room == nil {
+		return nil, nil
+	}
+	if !s.allowToken(w, r, room) {
+		return room, nil
+	}
+
+	player := room.PlayerByToken(token)
+	if player == nil {
+		s.recordTokenFailure(r, room)
+		http.Error(w, "プレイヤーとして参加していません", http.StatusForbidden)
+		return room, nil
+	}
+	return room, player
+}
+
+// ホスト用トークンを確認する関数
+// ホストでない場合はIPとルームの組の失敗として記録し、エラーレスポンスを書き込んでfalseを返す
+func (s *Server) requireHost(w http.ResponseWriter, r *http.Request, room *Room, token string) bool {
+	if !s.allowToken(w, r, room) {
+		return false
+	}
+	if room.IsHost(token) {
+		return true
+	}
+	s.recordTokenFailure(r, room)
+	http.Error(w, "ホストではありません", http.StatusForbidden)
+	return false
+}
+
+// トークンの推測が続いているIPを制限する関数
+// 許可されない場合はエラーレスポンスを書き込んでfalseを返す
+func (s *Server) allowToken(w http.ResponseWriter, r *http.Request, room *Room) bool {
+	ok, retryAfter := s.limiter.Allow(s.clientIP(r), room.ID)
+	if !ok {
+		writeRateLimited(w, retryAfter)
+	}
+	return ok
+}
+
+// トークンの不一致を記録する関数
+func (s *Server) recordTokenFailure(r *http.Request, room *Room) {
+	ip := s.clientIP(r)
+	s.limiter.RecordTokenFailure(ip, room.ID)
+	s.requestLogger(r).Info("トークンが一致しませんでした", LogKeyEvent, "token_failed", LogKeyRoom, room.ID, "ip", ip)
+}
+
+// リクエスト元のIPアドレスを取得する関数
+// 接続元が信頼するリバースプロキシの場合だけX-Forwarded-Forを参照し、右から順に最初の信頼しないアドレスを使う
+func (s *Server) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !s.trustedProxy(host) {
+		return host // 直接の接続元はヘッダーを偽装できるため信頼しない
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(addr) == nil {
+			break // 不正な値より左は信頼できない
+		}
+		host = addr
+		if !s.trustedProxy(addr) {
+			break
+		}
+	}
+	return host
+}
+
+// trustedProxy アドレスが信頼するリバースプロキシのものかを返す
+func (s *Server) trustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range s.proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// レート制限時のレスポンスを返す関数
+func writeRateLimited(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(retryAfter.Seconds())
+	if retryAfter%time.Second != 0 {
+		seconds++ // 切り上げる
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, "参加の試行回数が多すぎます。しばらくしてから再試行してください", http.StatusTooManyRequests)
+}
+
+// レート制限をWebSocketで通知する関数
+func writeRateLimitedJSON(conn *websocket.Conn, retryAfter time.Duration) {
+	conn.WriteJSON(map[string]interface{}{
+		"error":      "参加の試行回数が多すぎます",
+		"retryAfter": int(retryAfter.Seconds()),
+	})
+}
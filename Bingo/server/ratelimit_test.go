@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newTestLimiter 既定の制限とFakeClockでJoinLimiterを作成する
+func newTestLimiter() (*JoinLimiter, *FakeClock, *Metrics) {
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics()
+	limiter := NewJoinLimiter(clock, DefaultConfig().Limits, metrics, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return limiter, clock, metrics
+}
+
+func TestJoinLimiterBackoff(t *testing.T) {
+	limiter, clock, _ := newTestLimiter()
+
+	// 失敗するたびに待機時間が倍になり、JoinMaxDelayで頭打ちになる
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second, time.Minute} {
+		limiter.RecordFailure("192.0.2.1")
+		ok, retryAfter := limiter.AllowIP("192.0.2.1")
+		if ok || retryAfter != want {
+			t.Fatalf("%d回目の失敗の後: AllowIP = (%v, %v), want (false, %v)", i+1, ok, retryAfter, want)
+		}
+		clock.Advance(want - time.Millisecond)
+		if ok, _ := limiter.AllowIP("192.0.2.1"); ok {
+			t.Fatalf("%d回目の失敗の待機時間が終わる前に許可されました", i+1)
+		}
+		clock.Advance(time.Millisecond)
+		if ok, _ := limiter.AllowIP("192.0.2.1"); !ok {
+			t.Fatalf("%d回目の失敗の待機時間が終わっても許可されません", i+1)
+		}
+	}
+}
+
+func TestJoinLimiterLockout(t *testing.T) {
+	limiter, clock, metrics := newTestLimiter()
+
+	for i := 0; i < JoinMaxFailures; i++ {
+		limiter.RecordFailure("192.0.2.1")
+		if i < JoinMaxFailures-1 {
+			clock.Advance(JoinMaxDelay) // バックオフの終了を待ってから次を試す
+		}
+	}
+	ok, retryAfter := limiter.AllowIP("192.0.2.1")
+	if ok || retryAfter != JoinLockoutDuration {
+		t.Fatalf("AllowIP = (%v, %v), want (false, %v)", ok, retryAfter, JoinLockoutDuration)
+	}
+	if got := metrics.JoinLockouts.Value(); got != 1 {
+		t.Fatalf("JoinLockouts = %d, want 1", got)
+	}
+
+	clock.Advance(JoinLockoutDuration - time.Second)
+	if ok, _ := limiter.AllowIP("192.0.2.1"); ok {
+		t.Fatal("ロックアウト中に許可されました")
+	}
+	clock.Advance(time.Second)
+	if ok, _ := limiter.AllowIP("192.0.2.1"); !ok {
+		t.Fatal("ロックアウトが終わっても許可されません")
+	}
+	// ロックアウトの後は最初のバックオフからやり直す
+	limiter.RecordFailure("192.0.2.1")
+	if _, retryAfter := limiter.AllowIP("192.0.2.1"); retryAfter != JoinBaseDelay {
+		t.Fatalf("ロックアウト後の待機時間 = %v, want %v", retryAfter, JoinBaseDelay)
+	}
+}
+
+func TestJoinLimiterResetsAfterQuietPeriod(t *testing.T) {
+	limiter, clock, _ := newTestLimiter()
+
+	for i := 0; i < 3; i++ {
+		limiter.RecordFailure("192.0.2.1")
+		clock.Advance(JoinMaxDelay)
+	}
+	// 成功しても失敗の回数は数え直さない（自分のルームへの参加で推測を続けられないように）
+	if ok, _ := limiter.Allow("192.0.2.1", "room1"); !ok {
+		t.Fatal("バックオフの終了後に許可されません")
+	}
+	limiter.RecordFailure("192.0.2.1")
+	if _, retryAfter := limiter.AllowIP("192.0.2.1"); retryAfter != 8*time.Second {
+		t.Fatalf("4回目の失敗の待機時間 = %v, want 8s", retryAfter)
+	}
+
+	// しばらく失敗しなければ最初から数え直す
+	clock.Advance(JoinLockoutDuration + time.Second)
+	limiter.RecordFailure("192.0.2.1")
+	if _, retryAfter := limiter.AllowIP("192.0.2.1"); retryAfter != JoinBaseDelay {
+		t.Fatalf("間を空けた後の待機時間 = %v, want %v", retryAfter, JoinBaseDelay)
+	}
+}
+
+func TestJoinLimiterTokenFailuresAreScopedToIPAndRoom(t *testing.T) {
+	limiter, clock, _ := newTestLimiter()
+
+	for i := 0; i < JoinMaxFailures; i++ {
+		limiter.RecordTokenFailure("192.0.2.1", "room1")
+		if i < JoinMaxFailures-1 {
+			clock.Advance(JoinMaxDelay)
+		}
+	}
+
+	tests := []struct {
+		ip, room string
+		want     bool
+	}{
+		{"192.0.2.1", "room1", false}, // 推測したIPは同じルームでロックアウトされる
+		{"192.0.2.1", "room2", true},  // 他のルームには影響しない
+		{"192.0.2.2", "room1", true},  // 他のIPはルームに参加できる
+	}
+	for _, tt := range tests {
+		if ok, _ := limiter.Allow(tt.ip, tt.room); ok != tt.want {
+			t.Errorf("Allow(%s, %s) = %v, want %v", tt.ip, tt.room, ok, tt.want)
+		}
+	}
+	if ok, _ := limiter.AllowIP("192.0.2.1"); !ok {
+		t.Error("トークンの失敗でパスワードによる参加までIPごと制限されました")
+	}
+}
+
+// postJSONFrom X-Forwarded-Forで送信元を指定してJSONの本文でPOSTし、ステータスコードを返す
+func postJSONFrom(t *testing.T, url, ip string, body interface{}) int {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", ip)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST %s: %v", url, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestTokenGuessingIsSlowedWithoutLockingOutJoins(t *testing.T) {
+	s, clock, ts := newTestServer(t, func(cfg *Config) { cfg.TrustedProxies = []string{"127.0.0.1", "::1"} })
+	password, _ := createTestRoom(t, ts, map[string]interface{}{"interval": 5})
+	guess := map[string]string{"password": password, "hostToken": "guess"}
+
+	// ホスト用トークンを推測すると、次の試行はバックオフが終わるまで拒否される
+	if status := postJSONFrom(t, ts.URL+"/reset-winners", "203.0.113.1", guess); status != http.StatusForbidden {
+		t.Fatalf("1回目の推測: ステータス %d, want %d", status, http.StatusForbidden)
+	}
+	if status := postJSONFrom(t, ts.URL+"/reset-winners", "203.0.113.1", guess); status != http.StatusTooManyRequests {
+		t.Fatalf("バックオフ中の推測: ステータス %d, want %d", status, http.StatusTooManyRequests)
+	}
+	for i := 1; i < JoinMaxFailures+20; i++ {
+		clock.Advance(JoinMaxDelay)
+		postJSONFrom(t, ts.URL+"/reset-winners", "203.0.113.1", guess)
+	}
+	if s.metrics.JoinLockouts.Value() == 0 {
+		t.Fatal("推測を続けてもロックアウトされませんでした")
+	}
+
+	// 推測したIP以外の参加は制限されない
+	if status := postJSONFrom(t, ts.URL+"/join-room", "203.0.113.2", map[string]string{"password": password}); status != http.StatusOK {
+		t.Fatalf("他のIPの参加: ステータス %d, want %d", status, http.StatusOK)
+	}
+	if status := postJSONFrom(t, ts.URL+"/join-room", "203.0.113.1", map[string]string{"password": password}); status != http.StatusTooManyRequests {
+		t.Fatalf("推測したIPの参加: ステータス %d, want %d", status, http.StatusTooManyRequests)
+	}
+}
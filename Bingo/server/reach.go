@@ -0,0 +1,71 @@
+package server
+
+// ReachEvent あと一マスで勝ちになった（リーチ）ことの通知
+type ReachEvent struct {
+	Round      int    `json:"round"`                // ラウンド番号
+	PlayerID   string `json:"playerId"`             // リーチになったプレイヤーのID
+	PlayerName string `json:"playerName,omitempty"` // プレイヤーの表示名（名前を伏せるルームでは空）
+	CardID     string `json:"cardId"`               // リーチになったカードのID
+	Players    int    `json:"players"`              // リーチになっているプレイヤーの数
+}
+
+// reachPlayersLocked リーチになっているプレイヤーの数を返す（room.Mutexを保持して呼び出すこと）
+func (room *Room) reachPlayersLocked() int {
+	players := make(map[string]bool)
+	for _, playerID := range room.Round.Reach {
+		players[playerID] = true
+	}
+	return len(players)
+}
+
+// updateReachLocked 配られたカードのリーチ状態を更新し、新しくリーチになったカードを通知する
+// （room.Mutexを保持して呼び出すこと）
+func (room *Room) updateReachLocked() {
+	round := room.Round
+	if round.CurrentPrize() == nil {
+		return // すべての賞が決まっている
+	}
+
+	var reached []*IssuedCard
+	owners := make(map[string]string)
+	for playerID, cards := range round.Cards {
+		for _, card := range cards {
+			if card.Disqualified || round.checkerFor(card).Missing(card.Card, round.Game.Pattern) != 1 {
+				delete(round.Reach, card.ID) // 勝ちになった、または形が変わった
+				continue
+			}
+			if _, ok := round.Reach[card.ID]; !ok {
+				round.Reach[card.ID] = playerID
+				reached = append(reached, card)
+				owners[card.ID] = playerID
+			}
+		}
+	}
+
+	players := room.reachPlayersLocked()
+	for _, card := range reached {
+		ev := ReachEvent{
+			Round:    round.Number,
+			PlayerID: owners[card.ID],
+			CardID:   card.ID,
+			Players:  players,
+		}
+		if player := room.Players[ev.PlayerID]; player != nil && !room.HideReachNames {
+			ev.PlayerName = player.Name
+		}
+		room.publishLocked(RoomEvent{Type: "reach", Data: ev})
+		room.logger().Debug("リーチになりました", LogKeyEvent, "reach", LogKeyPlayer, ev.PlayerID, "round", round.Number, "players", players)
+	}
+}
+
+// CardMissing プレイヤーのカードについて、列ごとに揃うまでの残りマス数を返す
+func (room *Room) CardMissing(player *Player, cardID string) (map[string]int, error) {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	card := room.Round.cardFor(player.ID, cardID)
+	if card == nil {
+		return nil, ErrCardNotFound
+	}
+	return room.Round.checkerFor(card).Lines(card.Card), nil
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bingo/bingo"
+)
+
+// ラウンドとルームの設定に関するエラー
+var (
+	ErrRoomClosed      = errors.New("ルームは閉じられています")
+	ErrInvalidPattern  = errors.New("対応していない形です")
+	ErrInvalidInterval = errors.New("インターバルが範囲外です")
+	ErrCodeGeneration  = errors.New("ルームのコードを生成できませんでした")
+)
+
+// Round ルーム内の一回のゲーム
+type Round struct {
+	Number      int                      `json:"number"`            // ラウンド番号（1始まり）
+	Prizes      []Prize                  `json:"prizes"`            // 賞の設定（段階順）
+	Stage       int                      `json:"stage"`             // 現在の賞の段階（0始まり、すべて決まるとlen(Prizes)）
+	Game        *bingo.Game              `json:"-"`                 // 抽選と判定（現在の段階の形と引かれた数字）
+	Winners     []Win                    `json:"winners"`           // 確認済みの勝者（順位順）
+	Cards       map[string][]*IssuedCard `json:"-"`                 // プレイヤーIDごとに配られたカード
+	Reach       map[string]string        `json:"-"`                 // リーチになっているカードのIDとプレイヤーID
+	Claims      []ClaimRecord            `json:"claims"`            // 申告の記録（お手つきを含む）
+	FalseClaims map[string]int           `json:"-"`                 // プレイヤーIDごとのお手つきの回数
+	StartedAt   time.Time                `json:"startedAt"`         // ラウンドが作られた時刻
+	EndedAt     *time.Time               `json:"endedAt,omitempty"` // ラウンドが終わった時刻
+
+	pending *claimGroup // 受付時間の締め切りを待っている同時の申告
+}
+
+// 新しいRoundインスタンスを作成（prizesは確認済みであること）
+func newRound(number int, prizes []Prize, rng *rand.Rand, now time.Time) *Round {
+	return &Round{
+		Number:      number,
+		Game:        bingo.NewGame(prizes[0].Pattern, rng),
+		Prizes:      prizes,
+		Cards:       make(map[string][]*IssuedCard),
+		Reach:       make(map[string]string),
+		FalseClaims: make(map[string]int),
+		StartedAt:   now,
+	}
+}
+
+// MarshalJSON 現在の形と引かれた数字を含めてJSONに変換する
+func (round Round) MarshalJSON() ([]byte, error) {
+	type plain Round // MarshalJSONを引き継がない型
+	draws := round.Game.Draws()
+	if draws == nil {
+		draws = []bingo.Draw{} // 空の場合もJSONでは配列として返す
+	}
+	return json.Marshal(struct {
+		plain
+		Pattern bingo.Pattern `json:"pattern"` // 現在の段階で勝ちとなる形
+		Draws   []bingo.Draw  `json:"draws"`   // このラウンドで引かれた数字（引かれた順）
+	}{plain(round), round.Game.Pattern, draws})
+}
+
+// cardFor プレイヤーに配られたカードをIDで探す
+func (round *Round) cardFor(playerID, cardID string) *IssuedCard {
+	for _, card := range round.Cards[playerID] {
+		if card.ID == cardID {
+			return card
+		}
+	}
+	return nil
+}
+
+// checkerFor カードが配られた後に引かれた数字だけによる判定を返す
+func (round *Round) checkerFor(card *IssuedCard) *bingo.Checker {
+	return round.Game.CheckerAfter(card.IssuedAfter)
+}
+
+// snapshot 履歴として返すためにラウンドを複製する
+func (round *Round) snapshot() Round {
+	copied := *round
+	copied.Game = round.Game.Clone()
+	copied.Winners = append([]Win{}, round.Winners...)
+	copied.Prizes = append([]Prize{}, round.Prizes...)
+	copied.Claims = append([]ClaimRecord{}, round.Claims...)
+	copied.Cards = nil
+	copied.pending = nil
+	return copied
+}
+
+// NextRound 現在のラウンドを終えて次のラウンドを準備する
+// 賞を省略した場合は現在のラウンドと同じ賞を使う。プレイヤーはそのまま残り、カードは配り直しになる
+func (room *Room) NextRound(prizes []Prize) (*Round, error) {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	if room.State == RoomClosed {
+		return nil, ErrRoomClosed
+	}
+	if len(prizes) == 0 {
+		prizes = room.Round.Prizes
+	}
+	prizes, err := normalizePrizes(prizes)
+	if err != nil {
+		return nil, err
+	}
+
+	// 受付時間中の申告を確定させてから、現在のラウンドを終えて抽選を止める
+	room.resolveClaimsLocked()
+	now := room.now()
+	if room.Round.EndedAt == nil {
+		room.Round.EndedAt = &now // 賞がすべて決まって終わったラウンドはその時刻を残す
+	}
+	room.stopCountdownLocked()
+	room.NextDraw = time.Time{}
+
+	room.Round = newRound(room.Round.Number+1, prizes, room.manager.deckRng, now)
+	room.Rounds = append(room.Rounds, room.Round)
+	room.State = RoomLobby
+	room.Countdown = room.Interval
+	room.LastActivity = now
+	room.publishLocked(RoomEvent{Type: "round", Data: map[string]interface{}{
+		"number":  room.Round.Number,
+		"pattern": room.Round.Game.Pattern,
+		"prizes":  room.Round.Prizes,
+	}})
+
+	room.logger().Info("次のラウンドを準備しました", LogKeyEvent, "round_started", "round", room.Round.Number, "pattern", room.Round.Game.Pattern, "prizes", len(prizes))
+	return room.Round, nil
+}
+
+// RoundHistory ラウンドの履歴を返す（round が0の場合はすべて）
+func (room *Room) RoundHistory(number int) []Round {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	rounds := make([]Round, 0, len(room.Rounds))
+	for _, round := range room.Rounds {
+		if number == 0 || round.Number == number {
+			rounds = append(rounds, round.snapshot())
+		}
+	}
+	return rounds
+}
+
+// 次のラウンドに進むハンドラー関数（ホスト用）
+func (s *Server) NextRoundHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "無効なHTTPメソッド", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Password  string        `json:"password"`  // ルームのパスワード
+		HostToken string        `json:"hostToken"` // ホスト用トークン
+		Pattern   bingo.Pattern `json:"pattern"`   // 次のラウンドの形（賞を指定しない場合）
+		Prizes    []Prize       `json:"prizes"`    // 次のラウンドの賞（省略時は形、どちらもなければ同じ賞）
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.requestLogger(r).Warn("リクエストのデコードエラー", LogKeyEvent, "bad_request", "error", err)
+		http.Error(w, "リクエスト本文が無効です", http.StatusBadRequest)
+		return
+	}
+
+	room := s.lookupRoom(w, r, req.Password)
+	if room == nil {
+		return
+	}
+	if !s.requireHost(w, r, room, req.HostToken) {
+		return
+	}
+
+	prizes := req.Prizes
+	if len(prizes) == 0 && req.Pattern != "" {
+		prizes = defaultPrizes(req.Pattern)
+	}
+	round, err := room.NextRound(prizes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.scheduler.Wake() // 抽選のスケジュールを再計算する
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"round":   round.Number,
+		"pattern": round.Game.Pattern,
+		"prizes":  round.Prizes,
+	})
+}
+
+// ラウンドごとの履歴を返すハンドラー関数
+func (s *Server) RoundsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	number := 0
+	if v := query.Get("round"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "roundは1以上の整数で指定してください", http.StatusBadRequest)
+			return
+		}
+		number = n
+	}
+
+	room := s.lookupRoom(w, r, query.Get("password"))
+	if room == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"rounds": room.RoundHistory(number)})
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DrawScheduler構造体 ルームごとのインターバルに従って数字を引く
+type DrawScheduler struct {
+	rm      *RoomManager  // 対象のルームを管理するRoomManager
+	wake    chan struct{} // スケジュールの再計算を要求するチャネル
+	quit    chan struct{} // 停止要求用のチャネル
+	stopped chan struct{} // 停止完了を通知するチャネル
+	running atomic.Bool   // スケジューラーのゴルーチンが動作中かどうか
+}
+
+// 新しいDrawSchedulerインスタンスを作成
+func NewDrawScheduler(rm *RoomManager) *DrawScheduler {
+	return &DrawScheduler{
+		rm:      rm,
+		wake:    make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// Start スケジューラーのゴルーチンを起動する
+func (ds *DrawScheduler) Start() {
+	ds.running.Store(true)
+	go ds.run()
+}
+
+// Stop スケジューラーを停止し、ゴルーチンの終了を待つ
+func (ds *DrawScheduler) Stop() {
+	select {
+	case <-ds.quit:
+		// 既に停止要求済み
+	default:
+		close(ds.quit)
+	}
+	<-ds.stopped
+}
+
+// Running スケジューラーのゴルーチンが動作中かどうかを返す
+func (ds *DrawScheduler) Running() bool {
+	return ds.running.Load()
+}
+
+// Wake ルームの状態やインターバルが変わったときにスケジュールを再計算させる
+func (ds *DrawScheduler) Wake() {
+	select {
+	case ds.wake <- struct{}{}:
+	default:
+		// 既に再計算が予約されている
+	}
+}
+
+// run 次に数字を引くルームの時刻まで待機し、数字を引くループ
+func (ds *DrawScheduler) run() {
+	defer close(ds.stopped)
+	defer ds.running.Store(false)
+
+	for {
+		now := ds.rm.clock.Now()
+		next := ds.drawDue(now)
+
+		// 次の抽選時刻までタイマーを設定する（予定がなければWakeかStopを待つ）
+		var timer Timer
+		var timerC <-chan time.Time
+		if !next.IsZero() {
+			timer = ds.rm.clock.NewTimer(next.Sub(now))
+			timerC = timer.C()
+		}
+
+		select {
+		case <-timerC:
+		case <-ds.wake:
+		case <-ds.quit:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// drawDue 抽選時刻を過ぎたルームで数字を引き、次に最も早い抽選時刻を返す
+func (ds *DrawScheduler) drawDue(now time.Time) time.Time {
+	var next time.Time
+	var exhausted []*Room
+
+	for _, room := range ds.rm.ListRooms() {
+		room.Mutex.Lock()
+		if room.State != RoomRunning || room.NextDraw.IsZero() {
+			room.Mutex.Unlock()
+			continue
+		}
+
+		if !now.Before(room.NextDraw) {
+			if _, ok := room.drawLocked(); !ok {
+				exhausted = append(exhausted, room)
+				room.NextDraw = time.Time{}
+				room.Mutex.Unlock()
+				continue
+			}
+			room.NextDraw = now.Add(time.Duration(room.Interval) * time.Second)
+			room.Countdown = room.Interval
+		}
+
+		if next.IsZero() || room.NextDraw.Before(next) {
+			next = room.NextDraw
+		}
+		room.Mutex.Unlock()
+	}
+
+	// すべての数字を引き終えたルームはゲーム終了にする
+	for _, room := range exhausted {
+		if err := room.Transition(RoomFinished); err != nil {
+			room.logger().Error("ルームの終了に失敗しました", LogKeyEvent, "room_finish_failed", "error", err)
+		}
+	}
+
+	return next
+}
+
+// drawLocked 未使用の数字を一つ引いて記録する（room.Mutexを保持して呼び出すこと）
+// すべての数字を引き終えている場合はfalseを返す
+func (room *Room) drawLocked() (int, bool) {
+	room.resolveClaimsLocked() // 前の抽選に対する保留中の申告を確定させる
+
+	draw, ok := room.Round.Game.Draw(room.now())
+	if !ok {
+		return 0, false
+	}
+	number := draw.Number
+	room.manager.metrics.Draws.Inc()
+	room.publishLocked(newDrawEvent(room.Round.Number, draw))
+	room.autoDaubLocked(number)     // 自動マークが有効なルームではカードにマークする
+	room.updateReachLocked()        // 新しくリーチになったカードを通知する
+	room.publishLeaderboardLocked() // 勝ちに近いプレイヤーの一覧を通知する
+
+	// ルームの保存先に追記する
+	if err := room.manager.storage.AppendDraw(room.ID, room.Round.Number, number); err != nil {
+		room.logger().Error("数字の保存に失敗しました", LogKeyEvent, "storage_append_failed", "round", room.Round.Number, "error", err)
+	}
+
+	return number, true
+}
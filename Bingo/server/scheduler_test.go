@@ -0,0 +1,135 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"bingo/bingo"
+)
+
+// waitFor バックグラウンドのゴルーチンが条件を満たすまで待つ
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%sを待っている間にタイムアウトしました", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// countdown ルームのカウントダウンの残り秒数を返す
+func countdown(room *Room) int {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	return room.Countdown
+}
+
+// nextDrawEvent 次の数字が引かれるまでFakeClockを少しずつ進め、引かれた数字を返す
+// 購読したイベントで同期するため、数字が引かれていない間は待たずに時計を進める
+func nextDrawEvent(t *testing.T, clock *FakeClock, events chan RoomEvent) bingo.Draw {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatal("購読が終了しました")
+			}
+			if ev.Type == "draw" {
+				return ev.Data.(bingo.Draw)
+			}
+		case <-time.After(time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatalf("数字が引かれませんでした（時計: %s）", clock.Now().Sub(testStart))
+			}
+			clock.Advance(100 * time.Millisecond)
+		}
+	}
+}
+
+func TestSchedulerDrawsAtInterval(t *testing.T) {
+	s, clock, ts := newTestServer(t, nil)
+	s.Start()
+
+	password, _ := createTestRoom(t, ts, map[string]interface{}{"interval": 3})
+	room := s.Rooms().GetRoomByPassword(password)
+	events, _ := room.Subscribe("")
+	defer room.Unsubscribe(events)
+
+	// 待機中のルームでは時計を進めても数字は引かれない（開始後の最初の数字が1番目になる）
+	clock.Advance(10 * time.Second)
+	if err := room.Transition(RoomRunning); err != nil {
+		t.Fatal(err)
+	}
+	started := clock.Now()
+	s.scheduler.Wake()
+
+	for want := 1; want <= 3; want++ {
+		draw := nextDrawEvent(t, clock, events)
+		if draw.Ordinal != want {
+			t.Fatalf("引かれた数字の番号 = %d, want %d", draw.Ordinal, want)
+		}
+		// インターバルより早く引かれていないこと
+		if earliest := started.Add(time.Duration(3*want) * time.Second); draw.Time.Before(earliest) {
+			t.Fatalf("%d番目の数字が早すぎます: %v < %v", draw.Ordinal, draw.Time, earliest)
+		}
+	}
+
+	// 一時停止中は数字が引かれない（再開後の最初の数字が4番目になる）
+	if err := room.Transition(RoomPaused); err != nil {
+		t.Fatal(err)
+	}
+	s.scheduler.Wake()
+	clock.Advance(time.Minute)
+	if err := room.Transition(RoomRunning); err != nil {
+		t.Fatal(err)
+	}
+	resumed := clock.Now()
+	s.scheduler.Wake()
+
+	draw := nextDrawEvent(t, clock, events)
+	if draw.Ordinal != 4 || draw.Time.Before(resumed) {
+		t.Fatalf("再開後の数字 = %+v, want 再開後に引かれた4番目の数字", draw)
+	}
+}
+
+func TestCountdownTicksWithClock(t *testing.T) {
+	s, clock, ts := newTestServer(t, nil)
+	// スケジューラーは起動しない（抽選によるカウントダウンの巻き戻しを避ける）
+
+	password, _ := createTestRoom(t, ts, map[string]interface{}{"interval": 5})
+	room := s.Rooms().GetRoomByPassword(password)
+	if err := room.Transition(RoomRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	// 1秒ごとに減り、0の次はインターバルより1少ない値に戻る（インターバルの秒数で一周する）
+	for _, want := range []int{4, 3, 2, 1, 0, 4, 3} {
+		clock.Advance(time.Second)
+		waitFor(t, "カウントダウン", func() bool { return countdown(room) == want })
+	}
+
+	// 一時停止中は減らず、再開時は残り時間を引き継ぐ
+	if err := room.Transition(RoomPaused); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "カウントダウンの停止", func() bool { return clock.Waiters() == 0 }) // ゴルーチンの終了を待てば、その後は待たずに確認できる
+	clock.Advance(2 * time.Second)
+	if got := countdown(room); got != 3 {
+		t.Fatalf("一時停止中のカウントダウン = %d, want 3", got)
+	}
+	if err := room.Transition(RoomRunning); err != nil {
+		t.Fatal(err)
+	}
+	room.Mutex.Lock()
+	untilDraw := room.NextDraw.Sub(clock.Now())
+	room.Mutex.Unlock()
+	if untilDraw != 3*time.Second {
+		t.Fatalf("再開後の次の抽選までの時間 = %v, want 3s", untilDraw)
+	}
+}
@@ -0,0 +1,820 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"bingo/bingo"
+
+	"github.com/gorilla/websocket"
+)
+
+// RoomManager構造体
+type RoomManager struct {
+	Rooms     map[string]*Room // ルームを管理するマップ
+	ViewCodes map[string]*Room // 閲覧専用コードからルームを引くためのマップ
+	Mutex     sync.Mutex       // Roomsへのアクセスを同期するためのミューテックス
+	config    Config           // サーバーの設定
+	storage   Storage          // 引かれた数字の保存先
+	clock     Clock            // 現在時刻を返す時計
+	rng       *rand.Rand       // 公開するIDの生成に使う乱数
+	deckRng   *rand.Rand       // 抽選の順番・カード・同着の抽選に使う乱数（IDとは別の乱数列）
+	logger    *slog.Logger     // ログの出力先
+	metrics   *Metrics         // 計測値
+}
+
+// Room構造体
+type Room struct {
+	ID                 string                      // ログや管理用のルームID（公開してよい識別子）
+	Password           string                      // ルームのパスワード
+	HostToken          string                      // ホスト操作用のトークン
+	ViewCode           string                      // 閲覧専用コード（大画面表示用）
+	Clients            map[*websocket.Conn]*Client // 接続されているクライアントのマップ
+	Players            map[string]*Player          // プレイヤーIDごとのプレイヤー
+	Mutex              sync.Mutex                  // Clientsへのアクセスを同期するためのミューテックス
+	Interval           int                         // ルーム全体のインターバル値
+	HideReachNames     bool                        // リーチの通知でプレイヤー名を伏せるか
+	AutoDaub           bool                        // 引かれた数字をサーバーがカードに自動でマークするか
+	AutoClaim          bool                        // 自動マークでビンゴになったカードをサーバーが自動で申告するか
+	FalseClaimPolicy   FalseClaimPolicy            // お手つきへの対応
+	FalseClaimCooldown int                         // お手つきで申告できなくする時間（秒）
+	ClaimWindowMs      int                         // 抽選後に同時の申告として受け付ける時間（ミリ秒、0の場合は先着）
+	TieBreak           TieBreak                    // 同時の申告が賞の枠を超えた場合の決め方
+	Countdown          int                         // インターバルの残り時間
+	State              RoomState                   // ルームの状態
+	CreatedAt          time.Time                   // ルームの作成時刻
+	LastActivity       time.Time                   // 最後に操作や参加があった時刻
+	Round              *Round                      // 現在のラウンド
+	Rounds             []*Round                    // これまでのラウンド（現在のラウンドを含む）
+	NextDraw           time.Time                   // 次に数字を引く時刻（進行中のみ）
+	done               chan struct{}               // ゴルーチンの終了シグナル用のチャネル
+	subscribers        map[chan RoomEvent]struct{} // イベントを購読しているクライアントのチャネル
+	manager            *RoomManager                // ルームを管理するRoomManager（保存先・時計・乱数を共有する）
+	creatorIP          string                      // ルームを作成したクライアントのIP（IPごとのルーム数の上限に使う）
+}
+
+// RoomOptions ルーム作成時に指定できる設定
+type RoomOptions struct {
+	HideReachNames bool `json:"hideReachNames"` // リーチの通知でプレイヤー名を伏せる
+	AutoDaub       bool `json:"autoDaub"`       // 引かれた数字をカードに自動でマークする
+	AutoClaim      bool `json:"autoClaim"`      // 自動マークでビンゴになったら自動で申告する
+
+	FalseClaimPolicy   FalseClaimPolicy `json:"falseClaimPolicy"`   // お手つきへの対応（省略時は警告のみ）
+	FalseClaimCooldown int              `json:"falseClaimCooldown"` // お手つきで申告できなくする時間（秒、省略時は30秒）
+
+	ClaimWindowMs int      `json:"claimWindowMs"` // 抽選後に同時の申告として受け付ける時間（ミリ秒、省略時は先着）
+	TieBreak      TieBreak `json:"tieBreak"`      // 同時の申告が賞の枠を超えた場合の決め方（省略時は先着順）
+}
+
+// 既定の設定を返す関数
+func defaultRoomOptions() RoomOptions {
+	var options RoomOptions
+	options.normalize() // 省略された値を補う（既定値では失敗しない）
+	return options
+}
+
+// 設定を確認して、省略された値を補う関数
+func (o *RoomOptions) normalize() error {
+	if o.FalseClaimPolicy == "" {
+		o.FalseClaimPolicy = FalseClaimWarn
+	}
+	if !o.FalseClaimPolicy.Valid() {
+		return errors.New("対応していないお手つきの対応です")
+	}
+	if o.FalseClaimCooldown < 0 {
+		return errors.New("お手つきの待ち時間は0以上で指定してください")
+	}
+	if o.FalseClaimCooldown == 0 {
+		o.FalseClaimCooldown = DefaultFalseClaimCooldown
+	}
+	if o.ClaimWindowMs < 0 || o.ClaimWindowMs > MaxClaimWindowMs {
+		return fmt.Errorf("申告の受付時間は0から%dミリ秒までで指定してください", MaxClaimWindowMs)
+	}
+	if o.TieBreak == "" {
+		o.TieBreak = TieBreakEarliest
+	}
+	if !o.TieBreak.Valid() {
+		return errors.New("対応していない同時の申告の決め方です")
+	}
+	return nil
+}
+
+// 新しいRoomManagerインスタンスを作成
+func NewRoomManager(config Config, storage Storage, clock Clock, rng, deckRng *rand.Rand, logger *slog.Logger) *RoomManager {
+	return &RoomManager{
+		config:    config,
+		Rooms:     make(map[string]*Room), // 新しいルームを作成するためのマップ
+		ViewCodes: make(map[string]*Room), // 閲覧専用コードのマップ
+		storage:   storage,
+		clock:     clock,
+		rng:       rng,
+		deckRng:   deckRng,
+		logger:    logger,
+		metrics:   NewMetrics(),
+	}
+}
+
+// now ルームの時計で現在時刻を返す
+func (room *Room) now() time.Time {
+	return room.manager.clock.Now()
+}
+
+// startCountdownLocked カウントダウンのゴルーチンを起動する（room.Mutexを保持して呼び出すこと）
+func (room *Room) startCountdownLocked() {
+	if room.done != nil || room.Interval <= 0 {
+		return // 既に起動済み、またはインターバルが無効
+	}
+	done := make(chan struct{})                         // 終了シグナル用のチャネルを作成
+	room.done = done                                    // 停止できるようにルームに保持する
+	ticker := room.manager.clock.NewTicker(time.Second) // 1秒ごとにtickするタイマーを作成
+
+	go func() {
+		defer ticker.Stop() // タイマーを停止する
+		for {
+			select {
+			case <-ticker.C():
+				room.Mutex.Lock()
+				room.Countdown = (room.Countdown - 1 + room.Interval) % room.Interval // インターバルのカウントダウンを計算する
+				room.Mutex.Unlock()
+			case <-done:
+				return // ゴルーチンを終了する
+			}
+		}
+	}()
+}
+
+// WebSocket接続を処理する関数
+func (s *Server) handleConnections(w http.ResponseWriter, r *http.Request) {
+	if s.rejectIfDraining(w) {
+		return // 停止処理中は新しい接続を受け付けない
+	}
+	// WebSocket 接続処理
+	logger := s.requestLogger(r)
+	conn, err := s.upgrader.Upgrade(w, r, nil) // WebSocketをアップグレードする
+	if err != nil {
+		logger.Warn("WebSocket アップグレード エラー", LogKeyEvent, "ws_upgrade_failed", "error", err)
+		return // エラーレスポンスはアップグレーダーが返している
+	}
+	defer conn.Close() // 関数終了時に接続を閉じる
+
+	// 初回メッセージでパスワード（観戦の場合は閲覧専用コード）を受け取る
+	var req struct {
+		Password    string `json:"password"`    // ルームのパスワード
+		ViewCode    string `json:"viewCode"`    // 観戦用の閲覧専用コード
+		PlayerToken string `json:"playerToken"` // 再接続時のプレイヤー用トークン
+		HostToken   string `json:"hostToken"`   // ホストとして接続する場合のホスト用トークン
+		Name        string `json:"name"`        // プレイヤーの表示名
+	}
+	if err := conn.ReadJSON(&req); err != nil {
+		logger.Warn("初回メッセージの読み取りエラー", LogKeyEvent, "ws_bad_join", "error", err)
+		conn.WriteMessage(websocket.TextMessage, []byte("初回メッセージの読み取りエラー")) // エラー詳細をクライアントに送信
+		return
+	}
+
+	// パスワードまたは閲覧専用コードが指定されている場合は試行回数を制限する
+	ip := s.clientIP(r)
+	code := req.Password
+	if code == "" {
+		code = req.ViewCode
+	}
+	if code != "" {
+		if ok, retryAfter := s.limiter.AllowIP(ip); !ok {
+			writeRateLimitedJSON(conn, retryAfter)
+			return
+		}
+	}
+
+	// ルームを作成または既存のルームに参加する
+	client := &Client{Role: RolePlayer, direct: make(chan RoomEvent, SubscriberBuffer), kicked: make(chan struct{})}
+	var room *Room
+	if req.Password != "" {
+		room = s.rooms.GetRoomByPassword(req.Password)
+	} else if req.ViewCode != "" {
+		room = s.rooms.GetRoomByViewCode(req.ViewCode)
+		client.Role = RoleSpectator
+	}
+	if room == nil && code != "" {
+		// パスワードが一致しない場合は失敗として記録する
+		s.limiter.RecordFailure(ip)
+		s.metrics.JoinFailures.Inc()
+		logger.Info("WebSocket: 部屋に参加できませんでした", LogKeyEvent, "join_failed", "ip", ip)
+		conn.WriteJSON(map[string]string{"error": "部屋に参加できませんでした"})
+		return
+	}
+	if room != nil {
+		if ok, retryAfter := s.limiter.Allow(ip, room.ID); !ok {
+			writeRateLimitedJSON(conn, retryAfter)
+			return
+		}
+	}
+	var resp map[string]interface{}
+	var added *Player // この接続で新しく登録したプレイヤー（ルームに接続できなかった場合は登録を取り消す）
+	if room == nil {
+		// ルームが存在しない場合は新しいルームを作成する
+		interval := s.config.DefaultInterval
+		roomPassword, err := s.rooms.CreateRoom(interval, defaultPrizes(bingo.PatternLine), defaultRoomOptions(), ip) // 新しいルームを作成する
+		if err != nil {
+			logger.Warn("WebSocket: ルームの上限に達したため作成を拒否しました", LogKeyEvent, "room_limit", "ip", ip, "error", err)
+			writeCapacityJSON(conn, err)
+			return
+		}
+
+		room = s.rooms.GetRoomByPassword(roomPassword) // ルームを更新
+		player, err := room.AddPlayer(req.Name)        // 作成したクライアントもプレイヤーとして登録
+		if err != nil {
+			writeCapacityJSON(conn, err)
+			return
+		}
+		added = player
+		client.PlayerID = player.ID
+		client.Host = true // 作成したクライアントがホストになる
+
+		// クライアントに新しいルームの情報を送信する
+		resp = map[string]interface{}{
+			"message":       "新しいルームが作成されました",
+			"roomPassword":  roomPassword,
+			"hostToken":     room.HostToken,
+			"viewCode":      room.ViewCode,
+			"playerId":      player.ID,
+			"playerToken":   player.Token,
+			"interval":      interval,
+			"remainingTime": interval, // 初回はインターバル値で設定
+			"state":         RoomLobby,
+		}
+	} else {
+		// 既存のルームに参加する（接続数が上限の場合はプレイヤーを登録せずに断る）
+		if err := room.CheckClientCapacity(); err != nil {
+			logger.Info("WebSocket: ルームの接続数が上限に達しています", LogKeyEvent, "room_full", LogKeyRoom, room.ID)
+			writeCapacityJSON(conn, err)
+			return
+		}
+		resp = map[string]interface{}{
+			"message": "部屋に参加しました",
+			"role":    client.Role,
+		}
+		if client.Role == RolePlayer {
+			// トークンがあれば同じプレイヤーとして再接続し、なければ新しく登録する
+			player := room.PlayerByToken(req.PlayerToken)
+			if player == nil && req.PlayerToken != "" {
+				s.limiter.RecordTokenFailure(ip, room.ID) // 一致しないトークンは推測の可能性があるため失敗として数える
+			}
+			if player == nil {
+				var err error
+				if player, err = room.AddPlayer(req.Name); err != nil {
+					logger.Info("WebSocket: ルームの参加者数が上限に達しています", LogKeyEvent, "player_limit", LogKeyRoom, room.ID)
+					writeCapacityJSON(conn, err)
+					return
+				}
+				added = player
+				resp["playerToken"] = player.Token
+			}
+			client.PlayerID = player.ID
+			resp["playerId"] = player.ID
+		}
+		if req.HostToken != "" {
+			if room.IsHost(req.HostToken) {
+				client.Host = true
+				resp["host"] = true
+			} else {
+				s.limiter.RecordTokenFailure(ip, room.ID)
+			}
+		}
+
+		room.Mutex.Lock()
+		resp["interval"] = room.Interval       // インターバルを取得
+		resp["remainingTime"] = room.Countdown // カウントダウンを取得
+		resp["state"] = room.State             // ルームの状態を取得
+		room.Mutex.Unlock()
+	}
+	logger = logger.With(LogKeyRoom, room.ID, LogKeyPlayer, client.PlayerID) // 以降のログにルームとプレイヤーを付ける
+	if err := room.AddClient(conn, client); err != nil {                     // クライアントをルームに追加
+		// 確認の後に接続数が埋まった場合は、登録だけのプレイヤーが残らないように取り消す
+		if added != nil {
+			room.RemovePlayer(added.ID)
+		}
+		logger.Info("WebSocket: ルームの接続数が上限に達しています", LogKeyEvent, "room_full")
+		writeCapacityJSON(conn, err)
+		return
+	}
+	if added != nil {
+		s.metrics.Joins.Inc() // 取り消したプレイヤーは数えない
+		logger.Info("プレイヤーが参加しました", LogKeyEvent, "player_joined", "ip", ip)
+	}
+
+	// クライアントにルームの情報を送信
+	conn.WriteJSON(resp)
+	client.send(RoomEvent{Type: "roster", Data: room.Roster()}) // 現在の参加者一覧を送る
+
+	// ルームのイベントをクライアントに転送する（接続への書き込みはこのゴルーチンだけが行う）
+	events, backlog := room.Subscribe("")
+	s.writers.Add(1)
+	go func() {
+		defer s.writers.Done()
+		defer conn.Close() // 購読が終了したら接続を閉じて再接続させる
+		for _, ev := range backlog {
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		}
+		for {
+			var ev RoomEvent
+			select {
+			case e, ok := <-events:
+				if !ok {
+					// 購読が終了した場合は再接続を促すために正常に切断する
+					closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, "")
+					conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+					return
+				}
+				ev = e
+			case ev = <-client.direct:
+			case <-client.kicked:
+				// 退出させられた場合は残っている通知を送ってから切断する
+				for {
+					select {
+					case ev := <-client.direct:
+						conn.WriteJSON(ev)
+						continue
+					default:
+					}
+					break
+				}
+				closeMessage := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "kicked")
+				conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+				return
+			}
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		}
+	}()
+
+	// クライアントからのメッセージを待機するループ
+	for {
+		var msg clientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			logger.Info("接続が切れました", LogKeyEvent, "ws_disconnected", "error", err)
+			room.Unsubscribe(events)
+			room.RemoveClient(conn) // クライアントを削除
+			break
+		}
+		room.Touch() // メッセージを受け取ったらアクティビティを更新
+		room.handleClientMessage(client, msg)
+	}
+}
+
+// ルーム作成関数
+// prizesは確認済みであること。ipは作成したクライアントのIPで、IPごとのルーム数の上限に使う
+func (rm *RoomManager) CreateRoom(interval int, prizes []Prize, options RoomOptions, ip string) (string, error) {
+	rm.Mutex.Lock()
+	defer rm.Mutex.Unlock()
+
+	if interval < 1 || interval > MaxIntervalSec {
+		return "", ErrInvalidInterval // 大きすぎる値は時間の計算で桁あふれする
+	}
+	if err := rm.checkRoomCapacityLocked(ip); err != nil {
+		return "", err
+	}
+
+	password := generateSecretCode(rm.logger, rm.config.CodeLength) // 推測されにくいパスワードを生成
+	for rm.Rooms[password] != nil {
+		password = generateSecretCode(rm.logger, rm.config.CodeLength) // 既存のルームと重複した場合は再生成
+	}
+	viewCode := generateSecretCode(rm.logger, ViewCodeLength) // 閲覧専用コードを生成
+	for rm.ViewCodes[viewCode] != nil {
+		viewCode = generateSecretCode(rm.logger, ViewCodeLength)
+	}
+	if password == "" || viewCode == "" {
+		return "", ErrCodeGeneration
+	}
+	id := generatePassword(rm.rng, RoomIDLength)
+	for rm.roomByIDLocked(id) != nil {
+		id = generatePassword(rm.rng, RoomIDLength) // 保存先のファイル名に使うため重複させない
+	}
+	now := rm.clock.Now()
+	round := newRound(1, prizes, rm.deckRng, now)
+	room := &Room{
+		ID:                 id,
+		Password:           password,                          // パスワードを設定
+		HostToken:          generateToken(rm.logger),          // ホスト用トークンを発行
+		ViewCode:           viewCode,                          // 閲覧専用コードを設定
+		Clients:            make(map[*websocket.Conn]*Client), // WebSocket接続のマップを初期化
+		Players:            make(map[string]*Player),          // プレイヤーのマップを初期化
+		Interval:           interval,                          // インターバルを設定
+		HideReachNames:     options.HideReachNames,
+		AutoDaub:           options.AutoDaub,
+		AutoClaim:          options.AutoDaub && options.AutoClaim, // 自動申告は自動マークが有効な場合のみ
+		FalseClaimPolicy:   options.FalseClaimPolicy,
+		FalseClaimCooldown: options.FalseClaimCooldown,
+		ClaimWindowMs:      options.ClaimWindowMs,
+		TieBreak:           options.TieBreak,
+		Countdown:          interval,  // カウントダウンを初期化
+		State:              RoomLobby, // 待機中の状態で作成
+		Round:              round,
+		Rounds:             []*Round{round},
+		CreatedAt:          now,
+		LastActivity:       now,
+		manager:            rm,
+		creatorIP:          ip,
+	}
+
+	rm.Rooms[password] = room     // パスワードをキーにしてルームを登録
+	rm.ViewCodes[viewCode] = room // 閲覧専用コードでも引けるように登録
+
+	room.logger().Info("新しいルームが作成されました", LogKeyEvent, "room_created", "interval", interval, "rooms", len(rm.Rooms))
+
+	return password, nil // 作成したルームのパスワードを返す
+}
+
+// 部屋を作成するハンドラー関数
+func (s *Server) CreateRoomHandler(w http.ResponseWriter, r *http.Request) {
+	if s.rejectIfDraining(w) {
+		return // 停止処理中は新しい接続を受け付けない
+	}
+	var req struct {
+		Interval    int     `json:"interval"` // リクエストからのインターバル値
+		Prizes      []Prize `json:"prizes"`   // 最初のラウンドの賞（省略時は一列揃えで人数無制限）
+		RoomOptions         // ルームの設定
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.requestLogger(r).Warn("リクエストのデコードエラー", LogKeyEvent, "bad_request", "error", err)
+		http.Error(w, "リクエストのデコードエラー", http.StatusBadRequest)
+		return
+	}
+	if req.Interval < 1 || req.Interval > MaxIntervalSec {
+		http.Error(w, fmt.Sprintf("インターバルは1から%dの範囲で指定してください", MaxIntervalSec), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Prizes) == 0 {
+		req.Prizes = defaultPrizes(bingo.PatternLine)
+	}
+	prizes, err := normalizePrizes(req.Prizes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.RoomOptions.normalize(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ClaimWindowMs >= req.Interval*1000 {
+		http.Error(w, "申告の受付時間はインターバルより短くしてください", http.StatusBadRequest)
+		return
+	}
+
+	ip := s.clientIP(r)
+	password, err := s.rooms.CreateRoom(req.Interval, prizes, req.RoomOptions, ip) // リクエストされたインターバルで新しいルームを作成
+	if err != nil {
+		s.requestLogger(r).Warn("ルームの上限に達したため作成を拒否しました", LogKeyEvent, "room_limit", "ip", ip, "error", err)
+		writeCapacityError(w, err)
+		return
+	}
+	if password == "" {
+		s.requestLogger(r).Error("部屋の作成に失敗しました", LogKeyEvent, "room_create_failed")
+		http.Error(w, "部屋の作成に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	// パスワードに対応するルームを取得
+	room := s.rooms.GetRoomByPassword(password)
+	if room == nil {
+		s.requestLogger(r).Error("作成したルームが見つかりませんでした", LogKeyEvent, "room_create_failed")
+		http.Error(w, "ルームが見つかりませんでした", http.StatusInternalServerError)
+		return
+	}
+
+	// レスポンスデータを構築
+	resp := map[string]string{
+		"password":  room.Password,  // レスポンスにパスワードを含める
+		"hostToken": room.HostToken, // ホスト操作用のトークン
+		"viewCode":  room.ViewCode,  // 大画面表示用の閲覧専用コード
+	}
+
+	// レスポンスをJSON形式で返す
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ルームの数字をServer-Sent Eventsで配信するハンドラー関数
+func (s *Server) GetRoomNumbersHandler(w http.ResponseWriter, r *http.Request) {
+	if s.rejectIfDraining(w) {
+		return // 停止処理中は新しい接続を受け付けない
+	}
+	password := r.URL.Query().Get("password")
+
+	// パスワードが提供されていない場合のエラーハンドリング
+	if password == "" {
+		s.requestLogger(r).Info("パスワードが提供されていません", LogKeyEvent, "bad_request")
+		http.Error(w, "パスワードが提供されていません", http.StatusBadRequest)
+		return
+	}
+
+	// パスワードに対応するルームを取得
+	room := s.lookupRoom(w, r, password)
+	if room == nil {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "ストリーミングに対応していません", http.StatusInternalServerError)
+		return
+	}
+
+	// 再接続時は最後に受け取ったイベントIDの続きから送信する
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	events, backlog := room.Subscribe(lastEventID)
+	defer room.Unsubscribe(events)
+	logger := s.requestLogger(r).With(LogKeyRoom, room.ID)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // リバースプロキシでのバッファリングを無効化
+	w.WriteHeader(http.StatusOK)
+
+	// 取りこぼした数字を先に送信する
+	for _, ev := range backlog {
+		if err := writeSSE(logger, w, ev); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	// 接続を維持するために定期的にコメントを送信する
+	keepAlive := s.rooms.clock.NewTicker(SSEKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return // ルームが閉じられたか、受信が追いつかず切断された
+			}
+			if err := writeSSE(logger, w, ev); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C():
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return // クライアントが切断した
+		}
+	}
+}
+
+// イベントをSSEの形式で書き込む関数
+func writeSSE(logger *slog.Logger, w http.ResponseWriter, ev RoomEvent) error {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		logger.Error("JSONエンコードに失敗しました", LogKeyEvent, "encode_failed", "roomEvent", ev.Type, "error", err)
+		return err
+	}
+	if ev.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", ev.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	return err
+}
+
+// ルームに関する定数と構造体
+const (
+	PasswordLength       = 6                // ルームのパスワードの既定の長さ
+	ViewCodeLength       = 8                // 閲覧専用コードの長さ
+	RoomIDLength         = 10               // ルームIDの長さ
+	SSEKeepAliveInterval = 15 * time.Second // SSEの接続維持用コメントを送る間隔
+)
+
+// パスワードに基づいてルームを取得する関数
+func (rm *RoomManager) GetRoomByPassword(password string) *Room {
+	rm.Mutex.Lock()
+	defer rm.Mutex.Unlock()
+
+	return rm.Rooms[password] // パスワードに対応するルームを返す
+}
+
+// ルームに参加するためのハンドラー関数
+func (s *Server) JoinRoomHandler(w http.ResponseWriter, r *http.Request) {
+	if s.rejectIfDraining(w) {
+		return // 停止処理中は新しい接続を受け付けない
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "無効なHTTPメソッド", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"` // JSONからのパスワードリクエスト
+		Name     string `json:"name"`     // プレイヤーの表示名
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.requestLogger(r).Warn("リクエストのデコードエラー", LogKeyEvent, "bad_request", "error", err)
+		http.Error(w, "リクエスト本文が無効です", http.StatusBadRequest)
+		return
+	}
+
+	// 総当たり対策として失敗の多いIPを制限する
+	ip := s.clientIP(r)
+	if ok, retryAfter := s.limiter.AllowIP(ip); !ok {
+		s.requestLogger(r).Info("参加試行を拒否しました", LogKeyEvent, "join_rate_limited", "ip", ip, "retryAfter", retryAfter.String())
+		writeRateLimited(w, retryAfter)
+		return
+	}
+
+	room := s.rooms.GetRoomByPassword(req.Password)
+	if room == nil {
+		// パスワードに対応するルームが存在しない場合は参加できない
+		s.limiter.RecordFailure(ip)
+		s.metrics.JoinFailures.Inc()
+		s.requestLogger(r).Info("部屋に参加できませんでした", LogKeyEvent, "join_failed", "ip", ip)
+		http.Error(w, "部屋に参加できませんでした", http.StatusUnauthorized)
+		return
+	}
+	if ok, retryAfter := s.limiter.Allow(ip, room.ID); !ok {
+		s.requestLogger(r).Info("参加試行を拒否しました", LogKeyEvent, "join_rate_limited", LogKeyRoom, room.ID, "ip", ip, "retryAfter", retryAfter.String())
+		writeRateLimited(w, retryAfter)
+		return
+	}
+
+	// ルームに参加（参加者数が上限の場合は断る）
+	player, err := room.AddPlayer(req.Name)
+	if err != nil {
+		s.requestLogger(r).Info("ルームの参加者数が上限に達しています", LogKeyEvent, "player_limit", LogKeyRoom, room.ID)
+		writeCapacityError(w, err)
+		return
+	}
+
+	s.metrics.Joins.Inc()
+	s.requestLogger(r).Info("プレイヤーが参加しました", LogKeyEvent, "player_joined", LogKeyRoom, room.ID, LogKeyPlayer, player.ID, "ip", ip)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":     "部屋に参加しました",
+		"playerId":    player.ID,
+		"playerToken": player.Token, // カードの取得やビンゴの申告に使うトークン
+	})
+}
+
+// ビンゴカードを生成するハンドラー関数
+func (s *Server) NewGameHandler(w http.ResponseWriter, r *http.Request) {
+	// ルームが指定されていない場合はルームに紐づかないカードを返す
+	password := r.URL.Query().Get("password")
+	if password == "" {
+		bingoCard := bingo.NewCard(s.rooms.deckRng) // ビンゴカードを生成
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(bingoCard) // ビンゴカードをJSONで返す
+		return
+	}
+
+	// ルームのカードはプレイヤーにのみ配る（観戦者はトークンを持たない）
+	room, player := s.lookupPlayer(w, r, password, r.URL.Query().Get("playerToken"))
+	if player == nil {
+		return
+	}
+
+	card, err := room.IssueCard(player)
+	if err != nil {
+		writeCapacityError(w, err)
+		return
+	}
+	room.Mutex.Lock()
+	resp := map[string]interface{}{
+		"id":       card.ID,
+		"card":     card.Card,
+		"interval": room.Interval,
+		"autoDaub": room.AutoDaub,
+		// ラウンドの途中で配られたカードは、これより後に引かれた数字だけが有効になる
+		"issuedAfter": card.IssuedAfter,
+		"pattern":     room.Round.Game.Pattern, // 現在の賞で勝ちとなる形
+	}
+	room.Mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ビンゴチェックを行うハンドラー関数
+func (s *Server) CheckBingoHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Password    string      `json:"password"`    // ルームのパスワード（ルームで申告する場合）
+		PlayerToken string      `json:"playerToken"` // プレイヤー用トークン
+		CardID      string      `json:"cardId"`      // 配られたカードのID（ルームで申告する場合）
+		Card        bingo.Card  `json:"card"`        // ビンゴカード
+		Marked      bingo.Marks `json:"marked"`      // マークされたセルの状態
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.requestLogger(r).Warn("リクエストのデコードエラー", LogKeyEvent, "bad_request", "error", err)
+		http.Error(w, "リクエスト本文が無効です", http.StatusBadRequest)
+		return
+	}
+
+	// ルームでの申告はプレイヤーにのみ許可し、サーバー側の抽選結果で確認する
+	if req.Password != "" {
+		room, player := s.lookupPlayer(w, r, req.Password, req.PlayerToken)
+		if player == nil {
+			return
+		}
+
+		win, falseClaim, err := room.Claim(player, req.CardID)
+		logger := s.requestLogger(r).With(LogKeyRoom, room.ID, LogKeyPlayer, player.ID)
+		switch {
+		case err != nil:
+			logger.Info("ビンゴの申告を受け付けませんでした", LogKeyEvent, "claim_rejected", "card", req.CardID, "error", err)
+		case falseClaim != nil:
+			logger.Info("ビンゴの申告がお手つきでした", LogKeyEvent, "claim", "card", req.CardID, "result", "false")
+		case win == nil:
+			logger.Info("ビンゴの申告を受け付けました", LogKeyEvent, "claim", "card", req.CardID, "result", "pending")
+		default:
+			logger.Info("ビンゴの申告を受け付けました", LogKeyEvent, "claim", "card", req.CardID, "result", "win", "place", win.Place)
+		}
+
+		var cooldown *ClaimCooldownError
+		switch {
+		case errors.As(err, &cooldown):
+			w.Header().Set("Retry-After", strconv.Itoa(int(cooldown.RetryAfter.Round(time.Second).Seconds())))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		case errors.Is(err, ErrCardDisqualified):
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, ErrPrizesAwarded):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		resp := map[string]interface{}{"bingo": falseClaim == nil}
+		if falseClaim == nil && win == nil {
+			resp["pending"] = true // 受付時間の締め切り後に結果がイベントで届く
+		} else if win != nil {
+			resp["place"] = win.Place
+			resp["prize"] = win.Prize
+			resp["pattern"] = win.Pattern
+			resp["detail"] = win.Detail
+		} else {
+			resp["falseClaim"] = falseClaim // お手つきへの対応
+			if missing, err := room.CardMissing(player, req.CardID); err == nil {
+				resp["missing"] = missing // 列ごとの残りマス数
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	isBingo := bingo.WinningLine(req.Marked) != "" // いずれかの列が揃っていればビンゴ
+	resp := map[string]interface{}{                // レスポンスを準備
+		"bingo":   isBingo,
+		"missing": bingo.LineMissing(req.Marked), // 列ごとの残りマス数
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp) // ビンゴの結果をJSONで返す
+}
+
+// 生成された数字のリストをリセットするハンドラー関数
+// ホストがルームを指定した場合は同じ形で次のラウンドを準備する
+func (s *Server) ResetGeneratedNumbersHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if password := query.Get("password"); password != "" {
+		room := s.lookupRoom(w, r, password)
+		if room == nil {
+			return
+		}
+		if !s.requireHost(w, r, room, query.Get("hostToken")) {
+			return
+		}
+		if _, err := room.NextRound(nil); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.scheduler.Wake() // 抽選のスケジュールを再計算する
+	}
+
+	response := map[string]string{"message": "生成された番号はリセットされました"}
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "JSON 応答の生成に失敗しました", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonResponse) // レスポンスをJSONで返す
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"net/http"
+)
+
+// ShutdownEvent サーバーの停止をクライアントに知らせるイベントの内容
+type ShutdownEvent struct {
+	Message string `json:"message"` // 表示用のメッセージ
+}
+
+// Shutdown 新しい参加を止め、すべてのクライアントに停止を通知してから抽選を止めて保存先を書き出す
+// ルームのデータは削除しない。HTTPサーバー自体の停止は呼び出し側で行う
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.draining.Store(true) // 以降の参加・ルーム作成は拒否する
+
+	// 保留中の申告を確定させてから、クライアントに停止を通知する
+	rooms := s.rooms.ListRooms()
+	for _, room := range rooms {
+		room.Mutex.Lock()
+		room.resolveClaimsLocked()
+		room.publishLocked(RoomEvent{Type: "server_shutdown", Data: ShutdownEvent{Message: "サーバーを停止します"}})
+		room.Mutex.Unlock()
+	}
+
+	// 抽選のゴルーチンを止める（書き込み中の抽選は終わるまで待つ）
+	stopped := make(chan struct{})
+	go func() {
+		s.stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// カウントダウンを止め、購読を終了してWebSocketとSSEの接続を閉じさせる
+	// 通知のイベントはバッファに残っているため、購読者は受け取ってから終了する
+	for _, room := range rooms {
+		room.Mutex.Lock()
+		room.stopCountdownLocked()
+		room.closeSubscribersLocked()
+		room.Mutex.Unlock()
+	}
+
+	// WebSocketのクライアントが通知を受け取って切断されるのを待つ
+	written := make(chan struct{})
+	go func() {
+		s.writers.Wait()
+		close(written)
+	}()
+	select {
+	case <-written:
+	case <-ctx.Done():
+		s.logger.Warn("一部のクライアントへの通知が終わる前に停止します", LogKeyEvent, "shutdown_timeout")
+	}
+
+	if err := s.rooms.storage.Flush(); err != nil {
+		s.logger.Error("保存先の書き出しに失敗しました", LogKeyEvent, "storage_flush_failed", "error", err)
+		return err
+	}
+	s.logger.Info("停止の準備が完了しました", LogKeyEvent, "shutdown_ready", "rooms", len(rooms))
+	return nil
+}
+
+// Draining 停止処理中で新しい参加を受け付けていないかを返す
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+// rejectIfDraining 停止処理中であればエラーレスポンスを返してtrueを返す
+func (s *Server) rejectIfDraining(w http.ResponseWriter) bool {
+	if !s.Draining() {
+		return false
+	}
+	w.Header().Set("Connection", "close")
+	http.Error(w, "サーバーを停止しています", http.StatusServiceUnavailable)
+	return true
+}
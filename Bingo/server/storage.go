@@ -0,0 +1,178 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Storage 引かれた数字をルームのラウンドごとに保存する先（roomはルームID）
+type Storage interface {
+	AppendDraw(room string, round, number int) error // 引かれた数字を追記する
+	DeleteRoom(room string, rounds []int) error      // ルームのすべてのラウンドのデータを削除する
+	Flush() error                                    // 書き込んだ内容を確実に保存する
+	Ping() error                                     // 保存先が使える状態かを確認する
+}
+
+// OpenStorage 保存先を表す文字列から保存先を作成する
+// "file:ディレクトリ" はディレクトリ内のテキストファイルに、"memory:" はメモリ上に保存する
+func OpenStorage(dsn string) (Storage, error) {
+	scheme, rest, _ := strings.Cut(dsn, ":")
+	switch scheme {
+	case "file":
+		if rest == "" {
+			rest = "."
+		}
+		info, err := os.Stat(rest)
+		if err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("保存先のディレクトリが見つかりません: %s", rest)
+		}
+		return NewFileStorage(rest), nil
+	case "memory":
+		return NewMemoryStorage(), nil
+	default:
+		return nil, fmt.Errorf("対応していない保存先です（file: または memory:）: %s", dsn)
+	}
+}
+
+// FileStorage構造体 ラウンドごとにテキストファイルへ一行ずつ数字を保存する
+type FileStorage struct {
+	Dir   string              // ファイルを置くディレクトリ（空の場合はカレントディレクトリ）
+	mu    sync.Mutex          // dirtyへのアクセスを同期するためのミューテックス
+	dirty map[string]struct{} // 前回のFlush以降に書き込んだファイル
+}
+
+// 新しいFileStorageインスタンスを作成
+func NewFileStorage(dir string) *FileStorage {
+	return &FileStorage{Dir: dir, dirty: make(map[string]struct{})}
+}
+
+// fileName ルームIDとラウンド番号からファイル名を生成する
+// パスワードをファイル名やエラーメッセージに出さないように、公開してよいルームIDを使う
+func (fs *FileStorage) fileName(room string, round int) string {
+	return filepath.Join(fs.Dir, fmt.Sprintf("%s-%d.txt", room, round))
+}
+
+// AppendDraw ファイルに数字を一行追記する
+func (fs *FileStorage) AppendDraw(room string, round, number int) error {
+	// ファイルをオープン（追記モードで、存在しない場合は作成）
+	fileName := fs.fileName(room, round)
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	// ファイルに新しい数字を書き込む
+	if _, err := fmt.Fprintf(file, "%d\n", number); err != nil {
+		file.Close()
+		return err
+	}
+
+	// ファイルをクローズする
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	fs.dirty[fileName] = struct{}{}
+	fs.mu.Unlock()
+	return nil
+}
+
+// DeleteRoom ルームのデータファイルをラウンドごとに削除する
+func (fs *FileStorage) DeleteRoom(room string, rounds []int) error {
+	var firstErr error
+	for _, round := range rounds {
+		if err := os.Remove(fs.fileName(room, round)); err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Flush 前回のFlush以降に書き込んだファイルをディスクに同期する
+func (fs *FileStorage) Flush() error {
+	fs.mu.Lock()
+	dirty := fs.dirty
+	fs.dirty = make(map[string]struct{})
+	fs.mu.Unlock()
+
+	var firstErr error
+	for fileName := range dirty {
+		file, err := os.OpenFile(fileName, os.O_WRONLY, 0)
+		if os.IsNotExist(err) {
+			continue // 閉じられたルームのファイルは削除済み
+		}
+		if err == nil {
+			err = file.Sync()
+			file.Close()
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("ファイル %s の同期に失敗しました: %v", fileName, err)
+		}
+	}
+	return firstErr
+}
+
+// Ping ディレクトリにファイルを作成できるかを確認する
+func (fs *FileStorage) Ping() error {
+	dir := fs.Dir
+	if dir == "" {
+		dir = "."
+	}
+	file, err := os.CreateTemp(dir, ".bingo-ping-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// MemoryStorage構造体 数字をメモリ上に保存する（テストや一時的なサーバー用）
+type MemoryStorage struct {
+	mu    sync.Mutex
+	draws map[string][]int // "ルームID-ラウンド番号" ごとの数字
+}
+
+// 新しいMemoryStorageインスタンスを作成
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{draws: make(map[string][]int)}
+}
+
+// memoryKey ルームとラウンドからキーを生成する関数
+func memoryKey(room string, round int) string {
+	return fmt.Sprintf("%s-%d", room, round)
+}
+
+// AppendDraw 数字を追記する
+func (ms *MemoryStorage) AppendDraw(room string, round, number int) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	key := memoryKey(room, round)
+	ms.draws[key] = append(ms.draws[key], number)
+	return nil
+}
+
+// DeleteRoom ルームの数字を削除する
+func (ms *MemoryStorage) DeleteRoom(room string, rounds []int) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, round := range rounds {
+		delete(ms.draws, memoryKey(room, round))
+	}
+	return nil
+}
+
+// Flush メモリ上に保存しているため何もしない
+func (ms *MemoryStorage) Flush() error {
+	return nil
+}
+
+// Ping メモリ上に保存しているため常に使える
+func (ms *MemoryStorage) Ping() error {
+	return nil
+}
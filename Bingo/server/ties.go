@@ -0,0 +1,174 @@
+package server
+
+import (
+	"sort"
+	"time"
+)
+
+// TieBreak 受付時間内の同時の申告が賞の枠を超えた場合の決め方
+type TieBreak string
+
+// 同時の申告の決め方の一覧
+const (
+	TieBreakEarliest TieBreak = "earliest" // 先に申告した順に枠を与える
+	TieBreakRandom   TieBreak = "random"   // 抽選で枠を与える
+	TieBreakSplit    TieBreak = "split"    // 全員を勝者にして賞を分け合う
+)
+
+// 申告の受付時間の上限（ミリ秒）
+const MaxClaimWindowMs = 10000
+
+// Valid 対応している決め方かどうかを返す
+func (t TieBreak) Valid() bool {
+	switch t {
+	case TieBreakEarliest, TieBreakRandom, TieBreakSplit:
+		return true
+	}
+	return false
+}
+
+// pendingClaim 受付時間の締め切りを待っている申告
+type pendingClaim struct {
+	player  *Player     // 申告したプレイヤー
+	card    *IssuedCard // 申告されたカード
+	detail  string      // 揃ったマスの組み合わせ
+	ordinal int         // 何番目の抽選で形を満たしたか
+	time    time.Time   // 申告された時刻
+}
+
+// claimGroup 同じ抽選に対する同時の申告
+type claimGroup struct {
+	Stage    int            // 賞の段階
+	Ordinal  int            // 何番目の抽選に対する申告か
+	Deadline time.Time      // 受付時間の締め切り
+	Claims   []pendingClaim // 受け付けた申告（申告順）
+}
+
+// TieClaim 同時の申告の結果の一人分
+type TieClaim struct {
+	PlayerID   string    `json:"playerId"`   // 申告したプレイヤーのID
+	PlayerName string    `json:"playerName"` // 申告したプレイヤーの表示名
+	CardID     string    `json:"cardId"`     // 申告されたカードのID
+	Time       time.Time `json:"time"`       // 申告された時刻
+	Won        bool      `json:"won"`        // 賞を得たか
+}
+
+// has カードの申告が保留中かどうかを返す
+func (group *claimGroup) has(cardID string) bool {
+	if group == nil {
+		return false
+	}
+	for _, claim := range group.Claims {
+		if claim.card.ID == cardID {
+			return true
+		}
+	}
+	return false
+}
+
+// removePlayer プレイヤーの保留中の申告を取り除く
+func (group *claimGroup) removePlayer(playerID string) {
+	if group == nil {
+		return
+	}
+	claims := group.Claims[:0]
+	for _, claim := range group.Claims {
+		if claim.player.ID != playerID {
+			claims = append(claims, claim)
+		}
+	}
+	group.Claims = claims
+}
+
+// holdClaimLocked 受付時間中であれば申告を保留する（room.Mutexを保持して呼び出すこと）
+// 保留した場合はtrueを返す。受付時間を過ぎている場合は先に保留中の申告を確定させてfalseを返す
+func (room *Room) holdClaimLocked(claim pendingClaim) bool {
+	round := room.Round
+	last, ok := round.Game.Last()
+	if room.ClaimWindowMs <= 0 || !ok {
+		return false
+	}
+
+	deadline := last.Time.Add(time.Duration(room.ClaimWindowMs) * time.Millisecond)
+	if !claim.time.Before(deadline) {
+		room.resolveClaimsLocked() // 締め切りを過ぎた保留中の申告があれば確定させる
+		return false
+	}
+
+	if round.pending == nil {
+		group := &claimGroup{Stage: round.Stage, Ordinal: last.Ordinal, Deadline: deadline}
+		round.pending = group
+		room.manager.clock.AfterFunc(deadline.Sub(claim.time), func() {
+			room.Mutex.Lock()
+			defer room.Mutex.Unlock()
+			if room.Round.pending == group {
+				room.resolveClaimsLocked()
+			}
+		})
+	}
+	round.pending.Claims = append(round.pending.Claims, claim)
+	return true
+}
+
+// resolveClaimsLocked 保留中の申告をルームの決め方に従って確定させる（room.Mutexを保持して呼び出すこと）
+func (room *Room) resolveClaimsLocked() {
+	round := room.Round
+	group := round.pending
+	round.pending = nil
+	if group == nil || len(group.Claims) == 0 {
+		return
+	}
+	prize := round.CurrentPrize()
+	if prize == nil || group.Stage != round.Stage {
+		return // 保留中に賞が決まった
+	}
+
+	claims := group.Claims
+	sort.SliceStable(claims, func(i, j int) bool { return claims[i].time.Before(claims[j].time) })
+
+	// 賞の枠が足りない場合のみ決め方を適用する
+	winners := claims
+	share := 0.0
+	remaining := prize.Slots - round.stageWinners(round.Stage)
+	if prize.Slots > 0 && len(claims) > remaining {
+		switch room.TieBreak {
+		case TieBreakSplit:
+			share = float64(remaining) / float64(len(claims))
+		case TieBreakRandom:
+			winners = append([]pendingClaim{}, claims...)
+			room.manager.deckRng.Shuffle(len(winners), func(i, j int) { winners[i], winners[j] = winners[j], winners[i] })
+			winners = winners[:remaining]
+		default:
+			winners = claims[:remaining]
+		}
+	}
+
+	won := make(map[string]bool, len(winners))
+	for _, claim := range winners {
+		room.recordWinLocked(claim, len(claims), share)
+		won[claim.card.ID] = true
+	}
+
+	if len(claims) > 1 {
+		results := make([]TieClaim, 0, len(claims))
+		for _, claim := range claims {
+			results = append(results, TieClaim{
+				PlayerID:   claim.player.ID,
+				PlayerName: claim.player.Name,
+				CardID:     claim.card.ID,
+				Time:       claim.time,
+				Won:        won[claim.card.ID],
+			})
+		}
+		room.publishLocked(RoomEvent{Type: "tie", Data: map[string]interface{}{
+			"round":    round.Number,
+			"ordinal":  group.Ordinal,
+			"prize":    prize.Name,
+			"tieBreak": room.TieBreak,
+			"claims":   results,
+		}})
+		room.logger().Info("同時の申告を確定しました", LogKeyEvent, "tie_resolved", "round", round.Number, "claims", len(claims), "winners", len(winners), "tieBreak", room.TieBreak)
+	}
+
+	room.awardLocked() // 賞の枠が埋まっていれば次の段階に進む
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newClaimTestRoom 申告の受付時間を設定したルームを作成し、同じ数字のカードを持つ二人のプレイヤーを登録する
+// 最初のカードが一列揃うまで数字を引いた状態で返す
+func newClaimTestRoom(t *testing.T, tieBreak TieBreak) (*Room, *FakeClock, []*Player, []*IssuedCard) {
+	t.Helper()
+
+	s, clock, ts := newTestServer(t, nil)
+	password, _ := createTestRoom(t, ts, map[string]interface{}{
+		"interval":      60,
+		"claimWindowMs": 500,
+		"tieBreak":      tieBreak,
+		"prizes":        []Prize{{Pattern: "line", Slots: 1}},
+	})
+	room := s.Rooms().GetRoomByPassword(password)
+
+	var players []*Player
+	var cards []*IssuedCard
+	for _, name := range []string{"alice", "bob"} {
+		player, err := room.AddPlayer(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		card, err := room.IssueCard(player)
+		if err != nil {
+			t.Fatal(err)
+		}
+		players = append(players, player)
+		cards = append(cards, card)
+	}
+
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+	cards[1].Card = cards[0].Card // 同じ抽選で揃うようにする
+	for {
+		if _, ok := room.Round.checkerFor(cards[0]).Check(cards[0].Card, "line"); ok {
+			break
+		}
+		if _, ok := room.drawLocked(); !ok {
+			t.Fatal("すべて引き終えても一列揃いませんでした")
+		}
+	}
+	return room, clock, players, cards
+}
+
+// claimHeld 申告が受付時間中として保留されたことを確認する
+func claimHeld(t *testing.T, room *Room, player *Player, card *IssuedCard) {
+	t.Helper()
+
+	win, record, err := room.Claim(player, card.ID)
+	if err != nil || win != nil || record != nil {
+		t.Fatalf("Claim = (%v, %v, %v), want 保留", win, record, err)
+	}
+}
+
+func TestClaimWindowSplitsTie(t *testing.T) {
+	room, clock, players, cards := newClaimTestRoom(t, TieBreakSplit)
+
+	claimHeld(t, room, players[0], cards[0])
+	clock.Advance(100 * time.Millisecond)
+	claimHeld(t, room, players[1], cards[1])
+	if n := len(room.WinnersList()); n != 0 {
+		t.Fatalf("受付時間中に%d人の勝者が決まりました", n)
+	}
+
+	// 締め切りで二人とも勝者になり、枠を分け合う
+	clock.Advance(400 * time.Millisecond)
+	winners := room.WinnersList()
+	if len(winners) != 2 {
+		t.Fatalf("勝者の数 = %d, want 2", len(winners))
+	}
+	for i, win := range winners {
+		if win.PlayerID != players[i].ID || win.Tie != 2 || win.Share != 0.5 {
+			t.Fatalf("勝者%d = %+v, want プレイヤー %s, Tie 2, Share 0.5", i, win, players[i].ID)
+		}
+	}
+}
+
+func TestClaimWindowEarliestWins(t *testing.T) {
+	room, clock, players, cards := newClaimTestRoom(t, TieBreakEarliest)
+
+	claimHeld(t, room, players[1], cards[1])
+	clock.Advance(200 * time.Millisecond)
+	claimHeld(t, room, players[0], cards[0])
+
+	clock.Advance(299 * time.Millisecond)
+	if n := len(room.WinnersList()); n != 0 {
+		t.Fatalf("締め切りの前に%d人の勝者が決まりました", n)
+	}
+	clock.Advance(time.Millisecond)
+	winners := room.WinnersList()
+	if len(winners) != 1 || winners[0].PlayerID != players[1].ID || winners[0].Tie != 2 {
+		t.Fatalf("勝者 = %+v, want 先に申告したプレイヤー %s", winners, players[1].ID)
+	}
+}
+
+func TestClaimAfterWindowIsImmediate(t *testing.T) {
+	room, clock, players, cards := newClaimTestRoom(t, TieBreakSplit)
+
+	clock.Advance(500 * time.Millisecond) // 受付時間を過ぎてから申告する
+	win, record, err := room.Claim(players[0], cards[0].ID)
+	if err != nil || record != nil || win == nil {
+		t.Fatalf("Claim = (%v, %v, %v), want すぐに勝者", win, record, err)
+	}
+	if win.Tie != 0 || win.Share != 0 {
+		t.Fatalf("一人の勝者が同時の申告として記録されました: %+v", win)
+	}
+}
+
+func TestResetWinnersWaitsForClaimWindow(t *testing.T) {
+	room, clock, players, cards := newClaimTestRoom(t, TieBreakSplit)
+
+	claimHeld(t, room, players[0], cards[0])
+	if err := room.ResetWinners(); !errors.Is(err, ErrClaimWindowOpen) {
+		t.Fatalf("受付時間中のResetWinners = %v, want %v", err, ErrClaimWindowOpen)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if n := len(room.WinnersList()); n != 1 {
+		t.Fatalf("勝者の数 = %d, want 1", n)
+	}
+	// 一つしかない枠が埋まってラウンドが終わったため、締め切り後もやり直せない
+	if err := room.ResetWinners(); !errors.Is(err, ErrRoundEnded) {
+		t.Fatalf("締め切り後のResetWinners = %v, want %v", err, ErrRoundEnded)
+	}
+}
+
+func TestNextRoundResolvesPendingClaims(t *testing.T) {
+	room, _, players, cards := newClaimTestRoom(t, TieBreakSplit)
+
+	claimHeld(t, room, players[0], cards[0])
+	if _, err := room.NextRound(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+	previous := room.Rounds[0]
+	if len(previous.Winners) != 1 || previous.Winners[0].PlayerID != players[0].ID {
+		t.Fatalf("前のラウンドの勝者 = %+v, want 保留中だったプレイヤー %s", previous.Winners, players[0].ID)
+	}
+}
+
+func TestKickDropsPendingClaim(t *testing.T) {
+	room, clock, players, cards := newClaimTestRoom(t, TieBreakSplit)
+
+	claimHeld(t, room, players[0], cards[0])
+	claimHeld(t, room, players[1], cards[1])
+	if !room.KickPlayer(players[0].ID) {
+		t.Fatal("プレイヤーを退出させられませんでした")
+	}
+
+	// 退出させたプレイヤーは締め切り後も勝者にならず、残った一人が枠を分けずに得る
+	clock.Advance(500 * time.Millisecond)
+	winners := room.WinnersList()
+	if len(winners) != 1 || winners[0].PlayerID != players[1].ID || winners[0].Share != 0 {
+		t.Fatalf("勝者 = %+v, want 残ったプレイヤー %s だけ", winners, players[1].ID)
+	}
+}
+
+func TestRepeatedClaimDuringWindowIsRecordedOnce(t *testing.T) {
+	room, clock, players, cards := newClaimTestRoom(t, TieBreakSplit)
+
+	claimHeld(t, room, players[0], cards[0])
+	clock.Advance(100 * time.Millisecond)
+	claimHeld(t, room, players[0], cards[0]) // 受付時間中に同じカードで再び申告する
+
+	room.Mutex.Lock()
+	claims, held := len(room.Round.Claims), len(room.Round.pending.Claims)
+	room.Mutex.Unlock()
+	if claims != 1 || held != 1 {
+		t.Fatalf("申告の履歴 = %d件, 保留中 = %d件, want 1件ずつ", claims, held)
+	}
+	if got := room.manager.metrics.ClaimsValid.Value(); got != 1 {
+		t.Fatalf("ClaimsValid = %d, want 1", got)
+	}
+}
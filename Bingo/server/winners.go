@@ -0,0 +1,221 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"bingo/bingo"
+)
+
+// 申告に関するエラー
+var (
+	ErrCardNotFound    = errors.New("カードが見つかりません")
+	ErrClaimWindowOpen = errors.New("同時の申告の受付中です。締め切り後に再試行してください")
+	ErrRoundEnded      = errors.New("このラウンドは終了しています。次のラウンドを開始してください")
+)
+
+// Win 確認済みのビンゴの記録
+type Win struct {
+	Round      int           `json:"round"`           // ラウンド番号
+	PlayerID   string        `json:"playerId"`        // 勝者のプレイヤーID
+	PlayerName string        `json:"playerName"`      // 勝者の表示名
+	CardID     string        `json:"cardId"`          // ビンゴになったカードのID
+	Card       bingo.Card    `json:"card"`            // ビンゴになったカードの数字
+	Stage      int           `json:"stage"`           // 賞の段階（0始まり）
+	Prize      string        `json:"prize"`           // 賞の名前
+	Place      int           `json:"place"`           // ラウンド内で何番目の勝者か（1始まり）
+	Pattern    bingo.Pattern `json:"pattern"`         // 賞の形
+	Detail     string        `json:"detail"`          // 揃ったマスの組み合わせ（row-1, column-3 など）
+	Ordinal    int           `json:"ordinal"`         // 何番目の抽選でビンゴになったか
+	Tie        int           `json:"tie,omitempty"`   // 同時の申告の数（同時でなければ省略）
+	Share      float64       `json:"share,omitempty"` // 賞を分け合った場合の取り分（分けなければ省略）
+	Time       time.Time     `json:"time"`            // ビンゴが確認された時刻
+}
+
+// Claim プレイヤーのビンゴの申告をサーバー側の抽選結果で確認する
+// ビンゴが確認できた場合は勝者として記録してルームに通知する（申告の受付時間中は締め切り後に決まるためnilを返す）
+// ビンゴになっていない場合はお手つきとしてルームの設定に従って処理し、その記録を返す
+func (room *Room) Claim(player *Player, cardID string) (*Win, *ClaimRecord, error) {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	card := room.Round.cardFor(player.ID, cardID)
+	if card == nil {
+		return nil, nil, ErrCardNotFound
+	}
+	return room.claimCardLocked(player, card)
+}
+
+// claimCardLocked 申告の制限を確認してからカードを判定し、結果を履歴に残す（room.Mutexを保持して呼び出すこと）
+// 手動の申告と自動申告の両方がこの経路を通る
+func (room *Room) claimCardLocked(player *Player, card *IssuedCard) (*Win, *ClaimRecord, error) {
+	if err := room.checkClaimAllowedLocked(player, card, room.now()); err != nil {
+		return nil, nil, err
+	}
+	// 同じカードの申告を既に受け付けている場合は、履歴や計測を重ねずに同じ結果を返す
+	if win, ok := room.Round.acceptedClaim(card.ID); ok {
+		return win, nil, nil
+	}
+
+	win, valid, err := room.claimLocked(player, card)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !valid {
+		room.manager.metrics.ClaimsInvalid.Inc()
+		record := room.falseClaimLocked(player, card)
+		return nil, &record, nil
+	}
+	room.manager.metrics.ClaimsValid.Inc()
+	room.recordClaimLocked(player, card, true)
+	return win, nil, nil
+}
+
+// claimLocked カードがビンゴになっていれば勝者として記録する（room.Mutexを保持して呼び出すこと）
+// ビンゴになっていない場合はvalidがfalseになる
+// 申告の受付時間中の場合は同時の申告として保留し、winはnilを返す
+func (room *Room) claimLocked(player *Player, card *IssuedCard) (win *Win, valid bool, err error) {
+	round := room.Round
+	prize := round.CurrentPrize()
+	if prize == nil {
+		return nil, false, ErrPrizesAwarded
+	}
+	detail, ok := round.checkerFor(card).Check(card.Card, prize.Pattern)
+	if !ok {
+		return nil, false, nil
+	}
+
+	ordinal, _ := round.Game.CompletedAt(card.Card, prize.Pattern, card.IssuedAfter) // 申告した時点ではなく揃った抽選を記録する
+	claim := pendingClaim{player: player, card: card, detail: detail, ordinal: ordinal, time: room.now()}
+	if room.holdClaimLocked(claim) {
+		return nil, true, nil // 受付時間の締め切り後に決まる
+	}
+
+	recorded := room.recordWinLocked(claim, 1, 0)
+	room.awardLocked() // 賞の枠が埋まっていれば次の段階に進む
+	return &recorded, true, nil
+}
+
+// acceptedClaim カードの申告を現在の賞で既に受け付けているかを返す
+// 勝者として記録済みの場合はその記録を、保留中の場合はnilを返す
+func (round *Round) acceptedClaim(cardID string) (*Win, bool) {
+	for i := range round.Winners {
+		if round.Winners[i].CardID == cardID && round.Winners[i].Stage == round.Stage {
+			return &round.Winners[i], true
+		}
+	}
+	return nil, round.pending.has(cardID)
+}
+
+// recordWinLocked 申告を勝者として記録してルームに通知する（room.Mutexを保持して呼び出すこと）
+// tieは同時の申告の数（一人の場合は1）、shareは賞を分け合う場合の取り分（分けない場合は0）
+func (room *Room) recordWinLocked(claim pendingClaim, tie int, share float64) Win {
+	round := room.Round
+	prize := round.CurrentPrize()
+	win := Win{
+		Round:      round.Number,
+		Stage:      round.Stage,
+		Prize:      prize.Name,
+		Place:      len(round.Winners) + 1,
+		PlayerID:   claim.player.ID,
+		PlayerName: claim.player.Name,
+		CardID:     claim.card.ID,
+		Card:       claim.card.Card,
+		Pattern:    prize.Pattern,
+		Detail:     claim.detail,
+		Ordinal:    claim.ordinal,
+		Time:       claim.time,
+		Share:      share,
+	}
+	if tie > 1 {
+		win.Tie = tie
+	}
+	round.Winners = append(round.Winners, win)
+	room.LastActivity = room.now()
+	room.publishLocked(RoomEvent{Type: "winner", Data: win})
+
+	room.logger().Info("ビンゴを確認しました", LogKeyEvent, "winner", LogKeyPlayer, claim.player.ID, "round", round.Number, "prize", prize.Name, "place", win.Place, "detail", claim.detail)
+	return win
+}
+
+// WinnersList 現在のラウンドの勝者の一覧を順位順に返す
+func (room *Room) WinnersList() []Win {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	winners := make([]Win, len(room.Round.Winners))
+	copy(winners, room.Round.Winners)
+	return winners
+}
+
+// ResetWinners 現在のラウンドの勝者の一覧を消去してルームに通知する
+// 賞は最初の段階からやり直しになる。同時の申告の受付中は申告を失わないようにErrClaimWindowOpenを返す
+// 賞がすべて決まって終わったラウンドはやり直せないためErrRoundEndedを返す
+func (room *Room) ResetWinners() error {
+	room.Mutex.Lock()
+	defer room.Mutex.Unlock()
+
+	if room.Round.pending != nil {
+		return ErrClaimWindowOpen
+	}
+	if room.Round.EndedAt != nil {
+		return ErrRoundEnded
+	}
+	room.Round.Winners = nil
+	room.Round.Stage = 0
+	room.Round.Game.Pattern = room.Round.Prizes[0].Pattern
+	room.Round.Reach = make(map[string]string)
+	room.updateReachLocked()
+	room.publishLocked(RoomEvent{Type: "winners_reset", Data: struct{}{}})
+	return nil
+}
+
+// 勝者の一覧を返すハンドラー関数（ホスト用）
+func (s *Server) WinnersHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	room := s.lookupRoom(w, r, query.Get("password"))
+	if room == nil {
+		return
+	}
+	if !s.requireHost(w, r, room, query.Get("hostToken")) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"winners": room.WinnersList()})
+}
+
+// 勝者の一覧を消去するハンドラー関数（ホスト用）
+func (s *Server) ResetWinnersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "無効なHTTPメソッド", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Password  string `json:"password"`  // ルームのパスワード
+		HostToken string `json:"hostToken"` // ホスト用トークン
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.requestLogger(r).Warn("リクエストのデコードエラー", LogKeyEvent, "bad_request", "error", err)
+		http.Error(w, "リクエスト本文が無効です", http.StatusBadRequest)
+		return
+	}
+
+	room := s.lookupRoom(w, r, req.Password)
+	if room == nil {
+		return
+	}
+	if !s.requireHost(w, r, room, req.HostToken) {
+		return
+	}
+
+	if err := room.ResetWinners(); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "勝者の一覧をリセットしました"})
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWinOrdinalIsTheCompletingDraw(t *testing.T) {
+	room, clock, players, cards := newClaimTestRoom(t, TieBreakEarliest)
+
+	room.Mutex.Lock()
+	completed := len(room.Round.Game.Draws())
+	room.drawLocked() // 揃った後にも数字が引かれてから申告する
+	room.drawLocked()
+	room.Mutex.Unlock()
+
+	clock.Advance(time.Second) // 受付時間を過ぎてから申告する
+	win, _, err := room.Claim(players[0], cards[0].ID)
+	if err != nil || win == nil {
+		t.Fatalf("Claim = (%v, %v)", win, err)
+	}
+	if win.Ordinal != completed {
+		t.Fatalf("Ordinal = %d, want 揃った抽選の %d", win.Ordinal, completed)
+	}
+}
+
+func TestResetWinnersRefusedAfterRoundEnded(t *testing.T) {
+	room, clock, players, cards := newClaimTestRoom(t, TieBreakEarliest)
+
+	clock.Advance(time.Second)
+	if win, _, err := room.Claim(players[0], cards[0].ID); err != nil || win == nil {
+		t.Fatalf("Claim = (%v, %v)", win, err)
+	}
+
+	// 最後の枠が埋まって終わったラウンドはやり直せず、勝者も残る
+	if err := room.ResetWinners(); !errors.Is(err, ErrRoundEnded) {
+		t.Fatalf("ResetWinners = %v, want %v", err, ErrRoundEnded)
+	}
+	if n := len(room.WinnersList()); n != 1 {
+		t.Fatalf("勝者の数 = %d, want 1", n)
+	}
+	// 同じカードで申告し直しても新しい順位は与えられない
+	win, _, err := room.Claim(players[1], cards[1].ID)
+	if !errors.Is(err, ErrPrizesAwarded) || win != nil {
+		t.Fatalf("終了後のClaim = (%v, %v), want %v", win, err, ErrPrizesAwarded)
+	}
+}